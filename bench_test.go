@@ -0,0 +1,278 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+// benchBoard describes one representative board for benchmarks
+type benchBoard struct {
+	size   int
+	snakes int
+	late   bool
+	// crowded puts every opponent's head within reach of one of our moves
+	crowded bool
+}
+
+func (b benchBoard) String() string {
+	phase := "early"
+	if b.late {
+		phase = "late"
+	}
+	if b.crowded {
+		phase = "crowded"
+	}
+	return fmt.Sprintf("%dx%d/%dsnakes/%s", b.size, b.size, b.snakes, phase)
+}
+
+// benchBoards is every combination of board size, snake count and phase,
+// plus crowded boards where several opponents can answer our move
+func benchBoards() []benchBoard {
+	var boards []benchBoard
+	for _, size := range []int{7, 11, 19, 25} {
+		for _, snakes := range []int{2, 4, 8} {
+			for _, late := range []bool{false, true} {
+				boards = append(boards, benchBoard{size, snakes, late, false})
+			}
+		}
+	}
+	for _, size := range []int{11, 19} {
+		boards = append(boards, benchBoard{size, 8, false, true})
+	}
+	return boards
+}
+
+// state generates the board deterministically. Early game snakes have length
+// 3 and full health; late game snakes share about half the board between them.
+// Our snake always has room to move, so the whole strategy is timed rather
+// than the shortcut taken when we have no valid move.
+func (b benchBoard) state() GameState {
+	if b.crowded {
+		return crowdedState(b.size, b.snakes-1)
+	}
+	seed := int64(b.size*100 + b.snakes*10)
+	if b.late {
+		seed++
+	}
+	length := 3
+	if b.late {
+		length = max(3, b.size*b.size/2/b.snakes)
+	}
+	state := generateState(rand.New(rand.NewSource(seed)), b.size, b.size, b.snakes, length, b.late, true)
+	if b.late {
+		state.Turn = length * 10
+	}
+	return state
+}
+
+// generateState places snakes as random walks of up to length segments, so
+// each is connected and none overlap, plus one food per snake. Snakes have
+// random health when damaged is set and full health otherwise. When roomy is
+// set, our snake (the first) starts away from the walls, its body trails away
+// from its head, and no other snake comes within two cells of its head.
+func generateState(rng *rand.Rand, width, height, snakes, length int, damaged, roomy bool) GameState {
+	var state GameState
+	state.Game.ID = "generated"
+	state.Game.Ruleset.Name = "standard"
+	state.Board.Width, state.Board.Height = width, height
+
+	occupied := make(map[Coordinate]bool)
+	empty := func(c Coordinate) bool {
+		return onBoard(c, state.Board) && !occupied[c]
+	}
+	randomEmpty := func() (Coordinate, bool) {
+		for tries := 0; tries < 100; tries++ {
+			c := Coordinate{X: rng.Intn(width), Y: rng.Intn(height)}
+			if empty(c) {
+				return c, true
+			}
+		}
+		return Coordinate{}, false
+	}
+
+	var reserved []Coordinate
+	for i := 0; i < snakes; i++ {
+		ours := roomy && i == 0
+		start, ok := randomEmpty()
+		if ours && width > 4 && height > 4 {
+			start, ok = Coordinate{X: 2 + rng.Intn(width-4), Y: 2 + rng.Intn(height-4)}, true
+		}
+		if !ok {
+			break
+		}
+		body := []Coordinate{start}
+		occupied[start] = true
+		for len(body) < length {
+			var next []Coordinate
+			for _, dir := range allDirections {
+				if c := getNextPosition(body[len(body)-1], dir); empty(c) {
+					next = append(next, c)
+				}
+			}
+			if ours {
+				// Only the steps that lead away from the head
+				next = slices.DeleteFunc(next, func(c Coordinate) bool {
+					return manhattanDistance(c, start) <= manhattanDistance(body[len(body)-1], start)
+				})
+			}
+			if len(next) == 0 {
+				break
+			}
+			c := next[rng.Intn(len(next))]
+			body = append(body, c)
+			occupied[c] = true
+		}
+		if ours {
+			for x := start.X - 2; x <= start.X+2; x++ {
+				for y := start.Y - 2; y <= start.Y+2; y++ {
+					if c := (Coordinate{X: x, Y: y}); manhattanDistance(c, start) <= 2 && empty(c) {
+						occupied[c] = true
+						reserved = append(reserved, c)
+					}
+				}
+			}
+		}
+
+		health := 100
+		if damaged {
+			health = 1 + rng.Intn(99)
+		}
+		id := fmt.Sprintf("snake-%d", i)
+		state.Board.Snakes = append(state.Board.Snakes, Snake{
+			ID: id, Name: id, Health: health, Body: body, Head: body[0], Length: len(body),
+		})
+	}
+	for _, c := range reserved {
+		delete(occupied, c)
+	}
+	for i := 0; i < snakes; i++ {
+		if c, ok := randomEmpty(); ok {
+			state.Board.Food = append(state.Board.Food, c)
+			occupied[c] = true
+		}
+	}
+	if len(state.Board.Snakes) > 0 {
+		state.You = state.Board.Snakes[0]
+	}
+	return state
+}
+
+func BenchmarkCalculateNextMove(b *testing.B) {
+	for _, board := range benchBoards() {
+		state := board.state()
+		b.Run(board.String(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				calculateNextMove(state)
+			}
+		})
+	}
+}
+
+// BenchmarkEvaluateMove times scoring one move, with the danger map built
+// beforehand as explainNextMove does once per turn
+func BenchmarkEvaluateMove(b *testing.B) {
+	for _, board := range benchBoards() {
+		state := board.state()
+		pos := getNextPosition(state.You.Head, safeFallbackMove(state))
+		danger := newDangerMap(state, dangerHorizon)
+		b.Run(board.String(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				scoreMove(pos, state, state.You.Health, state.You.Length, defaultProfile, danger)
+			}
+		})
+	}
+}
+
+func BenchmarkFloodFill(b *testing.B) {
+	for _, board := range benchBoards() {
+		state := createStateWithMovedTails(board.state())
+		pos := getNextPosition(state.You.Head, safeFallbackMove(state))
+		b.Run(board.String(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				floodFill(pos, state, board.size*board.size, make(map[string]bool))
+			}
+		})
+	}
+}
+
+func BenchmarkIsValidMove(b *testing.B) {
+	for _, board := range benchBoards() {
+		state := board.state()
+		pos := getNextPosition(state.You.Head, "up")
+		b.Run(board.String(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				isValidMove(pos, state)
+			}
+		})
+	}
+}
+
+func BenchmarkGetPredictions(b *testing.B) {
+	for _, board := range benchBoards() {
+		state := board.state()
+		b.Run(board.String(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				newOpponentPredictor(state, nil, nil).getPredictions()
+			}
+		})
+	}
+}
+
+// defaultMoveBudget is the decision time allowed on any benchmark board. It
+// leaves room under the default move deadline for slower production hosts.
+const defaultMoveBudget = 100 * time.Millisecond
+
+// TestMoveBudget fails if calculateNextMove takes longer than the budget on
+// any benchmark board. MOVE_BUDGET overrides the budget, e.g. MOVE_BUDGET=50ms.
+func TestMoveBudget(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing test skipped in short mode")
+	}
+	budget := defaultMoveBudget
+	if v := os.Getenv("MOVE_BUDGET"); v != "" {
+		var err error
+		if budget, err = time.ParseDuration(v); err != nil {
+			t.Fatalf("invalid MOVE_BUDGET: %v", err)
+		}
+	}
+
+	var worst time.Duration
+	var worstBoard benchBoard
+	for _, board := range benchBoards() {
+		state := board.state()
+		// The fastest of a few runs, so a stray GC pause or busy machine
+		// does not fail the test
+		fastest := time.Duration(1<<63 - 1)
+		for run := 0; run < 3; run++ {
+			started := time.Now()
+			calculateNextMove(state)
+			fastest = min(fastest, time.Since(started))
+		}
+		t.Logf("%-24s %v", board, fastest)
+		if fastest > worst {
+			worst, worstBoard = fastest, board
+		}
+	}
+	if worst > budget {
+		t.Errorf("worst case decision took %v on %s, over the %v budget", worst, worstBoard, budget)
+	}
+}
+
+// TestBenchBoardsGiveUsRoom checks every benchmark board is a valid position
+// where our snake has a real choice to make, so the benchmarks time the whole
+// strategy rather than the shortcut for no or one valid move
+func TestBenchBoardsGiveUsRoom(t *testing.T) {
+	for _, board := range benchBoards() {
+		state := board.state()
+		if _, _, err := normalizeState(state, true); err != nil {
+			t.Errorf("%s: invalid board: %v", board, err)
+		}
+		if legal := legalMoves(state, state.You.ID); len(legal) < 2 {
+			t.Errorf("%s: our snake has %d legal move(s), want at least 2", board, len(legal))
+		}
+	}
+}
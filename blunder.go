@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// defaultBlunderDepth is how many turns before the loss the blunder finder
+// looks by default. Every turn further back multiplies the search by up to
+// three, once for each of our moves.
+const defaultBlunderDepth = 10
+
+// errNotLost is returned for a recorded game we did not lose
+var errNotLost = errors.New("the recorded game was not lost")
+
+// blunder is the last move of a lost game that a different move could have
+// saved
+type blunder struct {
+	// Turn is the turn the move was made from
+	Turn int
+	// Played is the recorded move, empty if it could not be told from the
+	// recording
+	Played string
+	// Alternative is a move that would have survived until after DeathTurn
+	Alternative string
+	// Won is set when the alternative also outlives every opponent
+	Won bool
+	// DeathTurn is the turn we were eliminated on
+	DeathTurn int
+	// Horizon is how many of our moves, counting the blunder itself, the loss
+	// was ahead of it: a search that deep would have seen it coming
+	Horizon int
+}
+
+func (b blunder) String() string {
+	played := b.Played
+	if played == "" {
+		played = "an unknown move"
+	}
+	outcome := "survived"
+	if b.Won {
+		outcome = "won"
+	}
+	return fmt.Sprintf("turn %d: played %s; %s would have %s. The loss on turn %d was %d move(s) ahead.",
+		b.Turn, played, b.Alternative, outcome, b.DeathTurn, b.Horizon)
+}
+
+// blunderSearch replays a recorded loss in the rules simulator. Opponents
+// repeat their recorded moves, or keep going straight where a move cannot be
+// told, and food appears where the recording shows it spawning.
+type blunderSearch struct {
+	frames    []GameState
+	opponents []map[string]string
+	played    []string
+	// death is the index of the last frame in which we are alive
+	death int
+}
+
+// findBlunder searches backward from the final position of a lost game for
+// the last turn where a different move would have let us survive past the
+// turn we died on, looking at most depth turns back: the last depth moves we
+// made, so a blunder's Horizon is never more than depth. It returns false if
+// no turn that close had a saving move.
+func findBlunder(frames []GameState, depth int) (blunder, bool, error) {
+	search, err := newBlunderSearch(frames)
+	if err != nil {
+		return blunder{}, false, err
+	}
+
+	for i := search.death; i >= 0 && i > search.death-depth; i-- {
+		found := false
+		var best blunder
+		for _, dir := range allDirections {
+			if dir == search.played[i] {
+				continue
+			}
+			next, alive := search.advance(frames[i], i, dir)
+			if !alive {
+				continue
+			}
+			survives, won := search.survives(next, i+1)
+			if !survives {
+				continue
+			}
+			if !found || (won && !best.Won) {
+				found = true
+				best = blunder{
+					Turn:        frames[i].Turn,
+					Played:      search.played[i],
+					Alternative: dir,
+					Won:         won && len(frames[i].Board.Snakes) > 1,
+					DeathTurn:   frames[search.death].Turn + 1,
+					Horizon:     search.death - i + 1,
+				}
+			}
+		}
+		if found {
+			return best, true, nil
+		}
+	}
+	return blunder{}, false, nil
+}
+
+func newBlunderSearch(frames []GameState) (blunderSearch, error) {
+	if len(frames) < 2 {
+		return blunderSearch{}, fmt.Errorf("a recorded game needs at least two turns, got %d", len(frames))
+	}
+	you := frames[0].You.ID
+	alive := func(state GameState) bool {
+		return slices.ContainsFunc(state.Board.Snakes, func(s Snake) bool { return s.ID == you })
+	}
+	if alive(frames[len(frames)-1]) {
+		return blunderSearch{}, errNotLost
+	}
+
+	search := blunderSearch{frames: frames, death: -1}
+	for i, frame := range frames {
+		if alive(frame) {
+			search.death = i
+		}
+	}
+	if search.death < 0 {
+		return blunderSearch{}, fmt.Errorf("our snake %s is on none of the recorded turns", you)
+	}
+
+	for i := 0; i <= search.death; i++ {
+		moves := make(map[string]string)
+		played := ""
+		if i+1 < len(frames) {
+			for id, turn := range observeOpponents(frames[i], frames[i+1]) {
+				if turn.Move != "" {
+					moves[id] = turn.Move
+				}
+			}
+			played = moveBetween(frames[i].You.Head, frames[i+1].You.Head)
+		}
+		search.opponents = append(search.opponents, moves)
+		search.played = append(search.played, played)
+	}
+	return search, nil
+}
+
+// advance plays turn i from state with our move, and reports whether we are
+// still on the board afterwards
+func (s blunderSearch) advance(state GameState, i int, move string) (GameState, bool) {
+	moves := map[string]string{state.You.ID: move}
+	for id, dir := range s.opponents[i] {
+		moves[id] = dir
+	}
+	state.Board.Hazards = s.frames[i].Board.Hazards
+	next := stepState(state, moves)
+
+	if i+1 < len(s.frames) {
+		for _, food := range s.frames[i+1].Board.Food {
+			if !slices.Contains(s.frames[i].Board.Food, food) && !slices.Contains(next.Board.Food, food) {
+				next.Board.Food = append(next.Board.Food, food)
+			}
+		}
+	}
+
+	for _, snake := range next.Board.Snakes {
+		if snake.ID == state.You.ID {
+			return next, true
+		}
+	}
+	return next, false
+}
+
+// survives reports whether some sequence of our moves from turn i keeps us
+// alive until after the turn we died on, and whether one of them also
+// outlives every opponent
+func (s blunderSearch) survives(state GameState, i int) (bool, bool) {
+	if len(state.Board.Snakes) == 1 {
+		return true, true
+	}
+	if i > s.death {
+		return true, false
+	}
+	survives := false
+	for _, dir := range legalMoves(state, state.You.ID) {
+		next, alive := s.advance(state, i, dir)
+		if !alive {
+			continue
+		}
+		ok, won := s.survives(next, i+1)
+		if won {
+			return true, true
+		}
+		survives = survives || ok
+	}
+	return survives, false
+}
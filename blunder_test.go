@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// playRecord plays our moves from state with the rules simulator and returns
+// every frame, as a recording would hold them
+func playRecord(t *testing.T, board string, moves ...string) []GameState {
+	t.Helper()
+	state, err := parseBoard(board)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := []GameState{state}
+	for _, move := range moves {
+		state = stepState(state, map[string]string{state.You.ID: move})
+		frames = append(frames, state)
+	}
+	return frames
+}
+
+func TestFindBlunder(t *testing.T) {
+	// Into the pocket, along it, and into our own body on the third move;
+	// only the first move could have been anything else
+	frames := playRecord(t, pocketBoard, "left", "left", "down")
+	found, ok, err := findBlunder(frames, defaultBlunderDepth)
+	if err != nil || !ok {
+		t.Fatalf("findBlunder = %v, %v", ok, err)
+	}
+	expected := blunder{Turn: 0, Played: "left", Alternative: "right", DeathTurn: 3, Horizon: 3}
+	if found != expected {
+		t.Errorf("findBlunder = %+v, expected %+v", found, expected)
+	}
+
+	// The search looks exactly depth moves back: three reach the blunder,
+	// two do not
+	if _, ok, _ := findBlunder(frames, 3); !ok {
+		t.Errorf("no blunder found within three turns of the loss")
+	}
+	if _, ok, _ := findBlunder(frames, 2); ok {
+		t.Errorf("found a blunder within two turns of the loss")
+	}
+}
+
+func TestFindBlunderLastMove(t *testing.T) {
+	frames := playRecord(t, ". . .\n. . .\nY y y'", "left")
+	found, ok, err := findBlunder(frames, defaultBlunderDepth)
+	if err != nil || !ok {
+		t.Fatalf("findBlunder = %v, %v", ok, err)
+	}
+	// The final frame keeps our last live state, so the move is unknown
+	if found.Turn != 0 || found.Played != "" || found.Alternative != "up" || found.Horizon != 1 {
+		t.Errorf("findBlunder = %+v", found)
+	}
+}
+
+func TestFindBlunderNotLost(t *testing.T) {
+	frames := playRecord(t, pocketBoard, "right")
+	if _, _, err := findBlunder(frames, defaultBlunderDepth); !errors.Is(err, errNotLost) {
+		t.Errorf("findBlunder on a game we survived: %v", err)
+	}
+}
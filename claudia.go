@@ -4,88 +4,172 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"time"
 )
 
-type Move struct {
-	Direction string
-	Score     float64
+// strategyProfile holds the tunable weights of the move evaluation, so that
+// differently tuned snakes can share one strategy
+type strategyProfile struct {
+	// RiskThreshold is the collision risk at or above which a move is only
+	// considered when nothing safer exists
+	RiskThreshold float64
+	// SpaceWeight scales the flood fill score; SpaceWeightLong is used
+	// instead once we are at or above the optimal length
+	SpaceWeight     float64
+	SpaceWeightLong float64
+	// UrgentHealth and HungryHealth are the health levels below which we
+	// seek food urgently or when short
+	UrgentHealth int
+	HungryHealth int
+	UrgentFood   float64
+	Food         float64
+	// AvoidFood is the penalty scale for food when we are already long enough
+	AvoidFood   float64
+	TailChasing float64
+	// AggressionBonus rewards being two cells from a smaller snake's head
+	AggressionBonus float64
+	// DefensiveMultiplier applies per larger snake within two cells
+	DefensiveMultiplier float64
+	HazardMultiplier    float64
+	// TrapBonus rewards sealing a confined opponent into less room than its
+	// length (see trapScore)
+	TrapBonus float64
+	// DangerPenalty is the penalty for a move whose nearby cells larger
+	// snakes can all reach first (see dangerScore)
+	DangerPenalty float64
+}
+
+// defaultProfile is the tuning calculateNextMove has always used
+var defaultProfile = strategyProfile{
+	RiskThreshold:       0.8,
+	SpaceWeight:         50,
+	SpaceWeightLong:     75,
+	UrgentHealth:        25,
+	HungryHealth:        50,
+	UrgentFood:          300,
+	Food:                150,
+	AvoidFood:           20,
+	TailChasing:         100,
+	AggressionBonus:     50,
+	DefensiveMultiplier: 0.7,
+	HazardMultiplier:    0.5,
+	TrapBonus:           250,
+	DangerPenalty:       400,
+}
+
+// moveContext is what a snake knows beyond the current game state when it
+// decides a move
+type moveContext struct {
+	Profile strategyProfile
+	// Models are the opponent models learned so far, keyed by snake ID
+	Models map[string]*opponentModel
+	// Opponents are the stored profiles of opponents met in earlier games,
+	// keyed by snake ID
+	Opponents map[string]*opponentProfile
 }
 
 // calculateNextMove determines the best move for the snake
 func calculateNextMove(gameState GameState) string {
+	return explainNextMove(gameState, moveContext{Profile: defaultProfile}).Move
+}
+
+// explainNextMove scores all four directions and picks the best one, keeping
+// every intermediate value so the decision can be inspected
+func explainNextMove(gameState GameState, mc moveContext) MoveExplanation {
+	phase := detectPhase(gameState)
+	profile := phaseProfile(mc.Profile, phase, gameState)
 	possibleMoves := []string{"up", "down", "left", "right"}
-	var validMoves []Move
+	explanation := MoveExplanation{Phase: phase.String()}
 
 	myHead := gameState.You.Head
 	myHealth := gameState.You.Health
 	myLength := gameState.You.Length
 
 	// Calculate opponent predictions with more advanced analysis
-	predictions := newOpponentPredictor(gameState).getPredictions()
+	predictions := newOpponentPredictor(gameState, mc.Models, mc.Opponents).getPredictions()
+
+	// Where opponents can be over the next few turns is the same whichever
+	// way we go, so it is mapped once for all four moves
+	danger := newDangerMap(gameState, dangerHorizon)
 
 	// Calculate scores for each possible move
+	candidates := 0
 	for _, direction := range possibleMoves {
+		breakdown := MoveBreakdown{Move: direction}
 		nextPos := getNextPosition(myHead, direction)
 
 		// Skip invalid moves
-		if !isValidMove(nextPos, gameState) {
-			continue
-		}
-
-		// Check for potential head-to-head collisions using advanced prediction
-		collisionRisk := calculateCollisionRisk(nextPos, predictions, myLength, gameState)
-		if collisionRisk >= 0.8 { // High risk threshold
-			continue
+		breakdown.Valid = isValidMove(nextPos, gameState)
+		if breakdown.Valid {
+			// Check for potential head-to-head collisions using advanced prediction
+			breakdown.CollisionRisk = calculateCollisionRisk(nextPos, predictions, myLength, gameState)
+			breakdown.moveScore = scoreMove(nextPos, gameState, myHealth, myLength, profile, danger)
+
+			// Adjust score based on collision risk
+			breakdown.Score = breakdown.Total * (1.0 - breakdown.CollisionRisk)
+
+			// High risk threshold
+			if breakdown.CollisionRisk < profile.RiskThreshold {
+				breakdown.Candidate = true
+				candidates++
+			}
 		}
 
-		score := evaluateMove(nextPos, gameState, myHealth, myLength)
-
-		// Adjust score based on collision risk
-		score *= (1.0 - collisionRisk)
-
-		validMoves = append(validMoves, Move{Direction: direction, Score: score})
+		explanation.Moves = append(explanation.Moves, breakdown)
 	}
 
-	// If no valid moves, try to accept moves with higher risk (better than guaranteed death)
-	if len(validMoves) == 0 {
-		for _, direction := range possibleMoves {
-			nextPos := getNextPosition(myHead, direction)
-			if !isValidMove(nextPos, gameState) {
-				continue
-			}
-
-			collisionRisk := calculateCollisionRisk(nextPos, predictions, myLength, gameState)
-			score := evaluateMove(nextPos, gameState, myHealth, myLength)
-
-			// Apply risk-based penalty
-			score *= (1.0 - collisionRisk)
-			score -= 200 // Additional penalty for high-risk moves
-
-			validMoves = append(validMoves, Move{Direction: direction, Score: score})
-		}
+	// Every move looks deadly: take the one most likely to survive
+	if candidates == 0 {
+		return rankLastResort(explanation, gameState, predictions)
 	}
 
-	if len(validMoves) == 0 {
-		return "up"
-	}
+	// Demote candidates that cut us off from our tail, then those an
+	// opponent reply could leave without an exit
+	applyEscapeCheck(&explanation, gameState)
+	applySafetyNet(&explanation, gameState)
 
-	bestMove := validMoves[0]
-	for _, move := range validMoves {
-		if move.Score > bestMove.Score {
+	var bestMove *MoveBreakdown
+	for i := range explanation.Moves {
+		move := &explanation.Moves[i]
+		if move.Candidate && (bestMove == nil || move.Score > bestMove.Score) {
 			bestMove = move
 		}
 	}
 
-	return bestMove.Direction
+	explanation.Move = bestMove.Move
+	return explanation
+}
+
+// moveScore is the breakdown of evaluateMove's score for a single position
+type moveScore struct {
+	Base             float64 `json:"base"`
+	Food             float64 `json:"food"`
+	Space            float64 `json:"space"`
+	TailChasing      float64 `json:"tailChasing"`
+	Aggression       float64 `json:"aggression"`
+	Trap             float64 `json:"trap"`
+	Danger           float64 `json:"danger"`
+	SafetyMultiplier float64 `json:"safetyMultiplier"`
+	// Veto names the critical safety check that short-circuited scoring, if any
+	Veto  string  `json:"veto,omitempty"`
+	Total float64 `json:"total"`
+	// escape is the move's escape route, when tail chasing worked it out
+	escape *escapeResult
 }
 
 // evaluateMove scores a potential move based on various factors with enhanced food strategy
 func evaluateMove(pos Coordinate, state GameState, myHealth int, myLength int) float64 {
+	return scoreMove(pos, state, myHealth, myLength, defaultProfile, newDangerMap(state, dangerHorizon)).Total
+}
+
+// scoreMove computes evaluateMove's score along with each of its components.
+// danger is the turn's danger map, built once by the caller.
+func scoreMove(pos Coordinate, state GameState, myHealth int, myLength int, profile strategyProfile, danger dangerMap) moveScore {
 	// Base score
-	score := 100.0
+	result := moveScore{Base: 100.0}
 
 	// Initialize safety multiplier
-	safetyMultiplier := 1.0
+	result.SafetyMultiplier = 1.0
 
 	// -------- CRITICAL SAFETY CHECKS (Massive Penalties) --------
 
@@ -97,20 +181,25 @@ func evaluateMove(pos Coordinate, state GameState, myHealth int, myLength int) f
 
 		headDist := manhattanDistance(pos, snake.Head)
 		if headDist == 1 && snake.Length >= myLength {
-			return -1000.0 // Extremely negative score to avoid certain death
+			// Extremely negative score to avoid certain death
+			result.Veto = "head-to-head"
+			result.Total = -1000.0
+			return result
 		}
 	}
 
 	// Check if the move leads to a potential trap
 	if isTrappedPosition(pos, state, 3) {
-		return -800.0
+		result.Veto = "trapped"
+		result.Total = -800.0
+		return result
 	}
 
 	// -------- HAZARD AVOIDANCE --------
 
 	for _, hazard := range state.Board.Hazards {
 		if pos.X == hazard.X && pos.Y == hazard.Y {
-			safetyMultiplier *= 0.5
+			result.SafetyMultiplier *= profile.HazardMultiplier
 		}
 	}
 
@@ -119,9 +208,6 @@ func evaluateMove(pos Coordinate, state GameState, myHealth int, myLength int) f
 	// Calculate optimal length based on other snakes
 	optimalLength := calculateOptimalLength(state)
 
-	// Initialize food score component
-	foodScore := 0.0
-
 	// Find closest food
 	closestFoodDist := math.MaxFloat64
 	var closestFood *Coordinate
@@ -155,11 +241,11 @@ func evaluateMove(pos Coordinate, state GameState, myHealth int, myLength int) f
 		shouldSeekFood := false
 		urgentFood := false
 
-		if myHealth < 25 {
+		if myHealth < profile.UrgentHealth {
 			// Emergency food seeking
 			urgentFood = true
 			shouldSeekFood = true
-		} else if myHealth < 50 {
+		} else if myHealth < profile.HungryHealth {
 			// Check if we're below optimal length
 			if myLength < optimalLength {
 				shouldSeekFood = true
@@ -174,13 +260,13 @@ func evaluateMove(pos Coordinate, state GameState, myHealth int, myLength int) f
 		// Calculate food score based on strategy
 		if shouldSeekFood && isFoodSafe {
 			if urgentFood {
-				foodScore = 300.0 / (closestFoodDist + 1)
+				result.Food = profile.UrgentFood / (closestFoodDist + 1)
 			} else {
-				foodScore = 150.0 / (closestFoodDist + 1)
+				result.Food = profile.Food / (closestFoodDist + 1)
 			}
 		} else if myLength > optimalLength {
 			// Slightly avoid food when we're already longer than optimal
-			foodScore = -20.0 / (closestFoodDist + 1)
+			result.Food = -profile.AvoidFood / (closestFoodDist + 1)
 		}
 	}
 
@@ -190,18 +276,22 @@ func evaluateMove(pos Coordinate, state GameState, myHealth int, myLength int) f
 
 	// Weight space more heavily when we're at or above optimal length
 	if myLength >= optimalLength {
-		score += spaceScore * 75 // Increased weight on space when we're long enough
+		result.Space = spaceScore * profile.SpaceWeightLong // Increased weight on space when we're long enough
 	} else {
-		score += spaceScore * 50
+		result.Space = spaceScore * profile.SpaceWeight
 	}
 
 	// -------- TAIL CHASING BEHAVIOR --------
 
-	// Encourage tail chasing when we're at or above optimal length and not hungry
-	if myLength >= optimalLength && myHealth > 50 {
+	// Encourage tail chasing when we're at or above optimal length and not
+	// hungry, and the tail can actually be reached
+	if myLength >= optimalLength && myHealth > profile.HungryHealth && len(state.You.Body) > 0 {
 		tailDist := manhattanDistance(pos, state.You.Body[len(state.You.Body)-1])
 		if tailDist <= 2 {
-			score += 100.0 / (float64(tailDist) + 1)
+			result.escape = newEscapeResult(pos, state)
+			if result.escape.tail {
+				result.TailChasing = profile.TailChasing / (float64(tailDist) + 1)
+			}
 		}
 	}
 
@@ -217,33 +307,51 @@ func evaluateMove(pos Coordinate, state GameState, myHealth int, myLength int) f
 		if myLength > snake.Length+1 {
 			// Aggressive positioning towards smaller snakes
 			if headDist == 2 {
-				score += 50.0
+				result.Aggression += profile.AggressionBonus
 			}
 		} else {
 			// Defensive positioning against larger snakes
 			if headDist <= 2 {
-				safetyMultiplier *= 0.7
+				result.SafetyMultiplier *= profile.DefensiveMultiplier
 			}
 		}
 	}
 
+	// -------- TRAPPING --------
+
+	result.Trap = trapScore(pos, state, profile)
+
+	// -------- CONVERGING OPPONENTS --------
+
+	result.Danger = dangerScore(pos, state, danger, profile)
+
 	// -------- FINAL SCORE CALCULATION --------
 
-	score = (score + foodScore) * safetyMultiplier
+	score := result.Base + result.Space + result.TailChasing + result.Aggression + result.Trap + result.Danger
+	result.Total = (score + result.Food) * result.SafetyMultiplier
 
-	return score
+	return result
 }
 
 // OpponentPredictor provides advanced opponent movement prediction
 type OpponentPredictor struct {
 	gameState GameState
 	cache     map[string]PredictionData
+	// models holds what we have learned about each opponent this game;
+	// opponents without one are predicted from the prior
+	models map[string]*opponentModel
+	// profiles holds what earlier games taught us about head-to-heads and
+	// response times
+	profiles map[string]*opponentProfile
 }
 
 type PredictionData struct {
 	LikelyMoves     []Coordinate
 	MoveProbability map[Coordinate]float64
 	Intent          MovementIntent
+	// HeadToHead is how the snake reacted to head-to-heads in earlier games,
+	// if we have met it before
+	HeadToHead *headToHeadStats
 }
 
 type MovementIntent struct {
@@ -252,10 +360,12 @@ type MovementIntent struct {
 	Trapped        bool
 }
 
-func newOpponentPredictor(state GameState) *OpponentPredictor {
+func newOpponentPredictor(state GameState, models map[string]*opponentModel, profiles map[string]*opponentProfile) *OpponentPredictor {
 	return &OpponentPredictor{
 		gameState: state,
 		cache:     make(map[string]PredictionData),
+		models:    models,
+		profiles:  profiles,
 	}
 }
 
@@ -282,16 +392,11 @@ func (op *OpponentPredictor) analyzeSnake(snake Snake) PredictionData {
 	data.Intent = intent
 
 	// Get all possible moves
-	directions := []string{"up", "down", "left", "right"}
+	moves, _, features := opponentMoveFeatures(op.gameState, snake)
 	totalWeight := 0.0
 
-	for _, dir := range directions {
-		nextPos := getNextPosition(snake.Head, dir)
-		if !isValidMove(nextPos, op.gameState) {
-			continue
-		}
-
-		weight := op.calculateMoveWeight(nextPos, snake, intent)
+	for i, nextPos := range moves {
+		weight := op.calculateMoveWeight(snake, features[i])
 		if weight > 0 {
 			data.LikelyMoves = append(data.LikelyMoves, nextPos)
 			data.MoveProbability[nextPos] = weight
@@ -306,9 +411,35 @@ func (op *OpponentPredictor) analyzeSnake(snake Snake) PredictionData {
 		}
 	}
 
+	if profile, ok := op.profiles[snake.ID]; ok {
+		data.HeadToHead = &profile.HeadToHead
+		op.expectTimeouts(snake, profile, data.MoveProbability)
+	}
+
 	return data
 }
 
+// expectTimeouts shifts probability to going straight for a snake that
+// usually answers close to the timeout: when it misses it, the engine moves
+// it in the direction it last went
+func (op *OpponentPredictor) expectTimeouts(snake Snake, profile *opponentProfile, probability map[Coordinate]float64) {
+	timeout := op.gameState.Game.Timeout
+	if timeout <= 0 {
+		timeout = int(defaultMoveTimeout / time.Millisecond)
+	}
+	if len(snake.Body) < 2 || profile.MeanLatency() < slowLatencyShare*float64(timeout) {
+		return
+	}
+	straight := getNextPosition(snake.Head, moveBetween(snake.Body[1], snake.Head))
+	if _, ok := probability[straight]; !ok {
+		return
+	}
+	for pos := range probability {
+		probability[pos] *= 1 - slowTimeoutChance
+	}
+	probability[straight] += slowTimeoutChance
+}
+
 func (op *OpponentPredictor) determineIntent(snake Snake) MovementIntent {
 	intent := MovementIntent{}
 
@@ -342,47 +473,14 @@ func (op *OpponentPredictor) determineIntent(snake Snake) MovementIntent {
 	return intent
 }
 
-func (op *OpponentPredictor) calculateMoveWeight(pos Coordinate, snake Snake, intent MovementIntent) float64 {
-	weight := 1.0
-
-	// Adjust weight based on food proximity if snake is seeking food
-	if intent.SeekingFood {
-		minFoodDist := math.MaxFloat64
-		for _, food := range op.gameState.Board.Food {
-			dist := float64(manhattanDistance(pos, food))
-			if dist < minFoodDist {
-				minFoodDist = dist
-			}
-		}
-		weight *= (10.0 / (minFoodDist + 1.0))
-	}
-
-	// Adjust weight based on aggressive behavior
-	if intent.AggressiveMode {
-		for _, otherSnake := range op.gameState.Board.Snakes {
-			if otherSnake.ID == snake.ID || otherSnake.ID == op.gameState.You.ID {
-				continue
-			}
-			if snake.Length > otherSnake.Length {
-				headDist := float64(manhattanDistance(pos, otherSnake.Head))
-				weight *= (5.0 / (headDist + 1.0))
-			}
-		}
-	}
-
-	// Reduce weight for moves that could trap the snake
-	if intent.Trapped {
-		availableNextMoves := 0
-		for _, dir := range []string{"up", "down", "left", "right"} {
-			nextPos := getNextPosition(pos, dir)
-			if isValidMove(nextPos, op.gameState) {
-				availableNextMoves++
-			}
-		}
-		weight *= float64(availableNextMoves) / 4.0
+// calculateMoveWeight weighs a candidate move by how much this opponent has
+// been seen to favor each of its features
+func (op *OpponentPredictor) calculateMoveWeight(snake Snake, features opponentFeatures) float64 {
+	model, ok := op.models[snake.ID]
+	if !ok {
+		model = newOpponentModel()
 	}
-
-	return weight
+	return model.weight(features)
 }
 
 func calculateCollisionRisk(nextPos Coordinate, predictions map[string]PredictionData, myLength int, state GameState) float64 {
@@ -407,6 +505,9 @@ func calculateCollisionRisk(nextPos Coordinate, predictions map[string]Predictio
 					risk *= 0.5 // Decrease risk for shorter snakes
 				}
 
+				// Adjust risk based on how the snake met head-to-heads before
+				risk *= headToHeadFactor(prediction.HeadToHead, snake.Length > myLength)
+
 				// Adjust risk based on snake's intent
 				if prediction.Intent.AggressiveMode {
 					risk *= 1.3 // Increase risk if snake is in aggressive mode
@@ -425,6 +526,20 @@ func calculateCollisionRisk(nextPos Coordinate, predictions map[string]Predictio
 	return maxRisk
 }
 
+// headToHeadFactor scales the risk of meeting a snake head-on by how it
+// reacted in earlier games: how often it moved in on a shorter snake when
+// it would win, or stayed put when it would lose. An unknown snake, and one
+// that behaves like a coin flip, leave the risk unchanged.
+func headToHeadFactor(stats *headToHeadStats, wins bool) float64 {
+	if stats == nil {
+		return 1
+	}
+	if wins {
+		return 2 * stats.attackRate()
+	}
+	return 2 * (1 - stats.retreatRate())
+}
+
 // getNextPosition calculates the next position based on current position and direction
 func getNextPosition(current Coordinate, direction string) Coordinate {
 	switch direction {
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runCommand runs one of the offline tools; with no command the binary
+// starts the Battlesnake server as before
+func runCommand(name string, args []string) error {
+	switch name {
+	case "export":
+		return runExport(args)
+	case "replay":
+		return runReplay(args)
+	case "blunder":
+		return runBlunder(args)
+	default:
+		return fmt.Errorf("unknown command %q (expected export, replay or blunder)", name)
+	}
+}
+
+// runExport renders a recorded game to an animated GIF or per-turn SVG frames
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "gif", "output format: gif or svg")
+	out := fs.String("o", "", "output file (gif) or directory (svg)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *out == "" {
+		return fmt.Errorf("usage: export [-format gif|svg] -o OUTPUT GAME.jsonl")
+	}
+
+	frames, err := loadGameRecord(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "gif":
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		if err := exportGIF(f, frames); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	case "svg":
+		return exportSVGFrames(*out, frames)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+}
+
+// runReplay prints every turn of a recorded game to the terminal
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	plain := fs.Bool("plain", false, "disable ANSI colors")
+	turn := fs.Int("turn", -1, "only print this turn")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: replay [-plain] [-turn N] GAME.jsonl")
+	}
+
+	frames, err := loadGameRecord(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	for _, frame := range frames {
+		if *turn >= 0 && frame.Turn != *turn {
+			continue
+		}
+		fmt.Println(renderBoard(frame, renderOptions{ANSI: !*plain}))
+	}
+	return nil
+}
+
+// runBlunder finds the last move of a recorded loss that a different move
+// could have saved
+func runBlunder(args []string) error {
+	fs := flag.NewFlagSet("blunder", flag.ContinueOnError)
+	depth := fs.Int("depth", defaultBlunderDepth, "how many turns before the loss to search")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: blunder [-depth N] GAME.jsonl")
+	}
+
+	frames, err := loadGameRecord(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	found, ok, err := findBlunder(frames, *depth)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Printf("No move in the last %d turns before the loss would have survived it.\n", *depth)
+		return nil
+	}
+	fmt.Println(found)
+	return nil
+}
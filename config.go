@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strings"
+)
+
+// snakeConfig describes one hosted snake: where it is mounted, which
+// personality plays and how it looks on the board
+type snakeConfig struct {
+	// Name identifies the snake in environment overrides (SNAKE_<NAME>_COLOR)
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	ServerID    string `json:"serverId"`
+	Personality string `json:"personality"`
+	Author      string `json:"author"`
+	Color       string `json:"color"`
+	Head        string `json:"head"`
+	Tail        string `json:"tail"`
+	// Version defaults to the build version when empty
+	Version string `json:"version"`
+}
+
+// serverConfig is the contents of the SNAKES_CONFIG file
+type serverConfig struct {
+	Snakes []snakeConfig `json:"snakes"`
+	// RecordDir receives a recording of every finished game when set; the
+	// RECORD_DIR environment variable overrides it
+	RecordDir string `json:"recordDir"`
+	// FingerprintDB is the file opponent profiles are kept in across games;
+	// the FINGERPRINT_DB environment variable overrides it
+	FingerprintDB string `json:"fingerprintDb"`
+}
+
+// defaultServerConfig is used when SNAKES_CONFIG is not set
+func defaultServerConfig() serverConfig {
+	return serverConfig{Snakes: []snakeConfig{
+		{
+			Name:        "claudia",
+			Path:        "/claudia/",
+			ServerID:    ServerID,
+			Personality: "claudia",
+			Author:      "Dave-Smith",
+			Color:       "#7ABF36",
+			Head:        "all-seeing",
+			Tail:        "do-sammy",
+		},
+		{
+			Name:        "aggressive",
+			Path:        "/aggressive/",
+			ServerID:    "battlesnake/dave-smith/aggressive",
+			Personality: "aggressive",
+			Author:      "Dave-Smith",
+			Color:       "#D9412B",
+			Head:        "evil",
+			Tail:        "sharp",
+		},
+		{
+			Name:        "cautious",
+			Path:        "/cautious/",
+			ServerID:    "battlesnake/dave-smith/cautious",
+			Personality: "cautious",
+			Author:      "Dave-Smith",
+			Color:       "#3B7DD8",
+			Head:        "safe",
+			Tail:        "round-bum",
+		},
+	}}
+}
+
+// loadServerConfig reads the snake configuration from path, or returns the
+// defaults when path is empty. Environment overrides are applied either way.
+func loadServerConfig(path string, getenv func(string) string) (serverConfig, error) {
+	cfg := defaultServerConfig()
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return serverConfig{}, err
+		}
+		cfg = serverConfig{}
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return serverConfig{}, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	for i := range cfg.Snakes {
+		applySnakeEnv(&cfg.Snakes[i], getenv)
+	}
+	if dir := getenv("RECORD_DIR"); dir != "" {
+		cfg.RecordDir = dir
+	}
+	if path := getenv("FINGERPRINT_DB"); path != "" {
+		cfg.FingerprintDB = path
+	}
+	if err := cfg.validate(); err != nil {
+		return serverConfig{}, err
+	}
+	return cfg, nil
+}
+
+// applySnakeEnv overrides appearance fields from the environment. SNAKE_<FIELD>
+// applies to every snake and SNAKE_<NAME>_<FIELD> to one snake, e.g.
+// SNAKE_AUTHOR=me or SNAKE_CLAUDIA_COLOR=#ff00ff.
+func applySnakeEnv(snake *snakeConfig, getenv func(string) string) {
+	name := strings.ToUpper(strings.NewReplacer("-", "_", " ", "_").Replace(snake.Name))
+	fields := map[string]*string{
+		"AUTHOR":  &snake.Author,
+		"COLOR":   &snake.Color,
+		"HEAD":    &snake.Head,
+		"TAIL":    &snake.Tail,
+		"VERSION": &snake.Version,
+	}
+	for field, value := range fields {
+		if v := getenv("SNAKE_" + field); v != "" {
+			*value = v
+		}
+		if name == "" {
+			continue
+		}
+		if v := getenv("SNAKE_" + name + "_" + field); v != "" {
+			*value = v
+		}
+	}
+}
+
+func (cfg serverConfig) validate() error {
+	if len(cfg.Snakes) == 0 {
+		return fmt.Errorf("no snakes configured")
+	}
+	paths := make(map[string]bool)
+	serverIDs := make(map[string]bool)
+	for i, snake := range cfg.Snakes {
+		if snake.Path == "" {
+			return fmt.Errorf("snake %d (%s) has no path", i, snake.Name)
+		}
+		if _, ok := personalities[snake.Personality]; !ok {
+			return fmt.Errorf("snake %d (%s) has unknown personality %q", i, snake.Name, snake.Personality)
+		}
+		path := strings.TrimSuffix(snake.Path, "/")
+		if paths[path] {
+			return fmt.Errorf("path %s is used by more than one snake", snake.Path)
+		}
+		paths[path] = true
+		if snake.ServerID != "" {
+			if serverIDs[snake.ServerID] {
+				return fmt.Errorf("server ID %s is used by more than one snake", snake.ServerID)
+			}
+			serverIDs[snake.ServerID] = true
+		}
+	}
+	return nil
+}
+
+// routes builds the SnakeRoute for every configured snake; fingerprints may
+// be nil to play without opponent profiles
+func (cfg serverConfig) routes(fingerprints *fingerprintDB) []SnakeRoute {
+	version := buildVersion()
+	routes := make([]SnakeRoute, 0, len(cfg.Snakes))
+	for _, snake := range cfg.Snakes {
+		info := BattlesnakeInfoResponse{
+			APIVersion: "1",
+			Author:     snake.Author,
+			Color:      snake.Color,
+			Head:       snake.Head,
+			Tail:       snake.Tail,
+			Version:    snake.Version,
+		}
+		if info.Version == "" {
+			info.Version = version
+		}
+		name := snake.Name
+		if name == "" {
+			name = snake.Personality
+		}
+		serverID := snake.ServerID
+		if serverID == "" {
+			serverID = "battlesnake/dave-smith/" + name
+		}
+		sessions := newSessionStore(name, defaultSessionTTL, cfg.RecordDir, fingerprints)
+		routes = append(routes, personalities[snake.Personality].route(snake.Path, serverID, info, sessions))
+	}
+	return routes
+}
+
+// buildVersion identifies the running build: the module version when built
+// from a tagged module, otherwise the VCS revision stamped by go build
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "dev"
+	}
+	return versionFromBuildInfo(info)
+}
+
+func versionFromBuildInfo(info *debug.BuildInfo) string {
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+
+	var revision string
+	var modified bool
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if revision == "" {
+		return "dev"
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if modified {
+		revision += "-dirty"
+	}
+	return revision
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"testing"
+)
+
+func envMap(values map[string]string) func(string) string {
+	return func(key string) string { return values[key] }
+}
+
+func TestLoadServerConfigDefaults(t *testing.T) {
+	cfg, err := loadServerConfig("", envMap(nil))
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	routes := cfg.routes(nil)
+	if len(routes) != 3 || routes[0].Path != "/claudia/" || routes[0].ServerID != ServerID {
+		t.Fatalf("unexpected default routes: %+v", routes)
+	}
+	info := routes[0].Info()
+	if info.Author != "Dave-Smith" || info.Color != "#7ABF36" || info.Version == "" {
+		t.Errorf("unexpected default info: %+v", info)
+	}
+}
+
+func TestLoadServerConfigEnvOverrides(t *testing.T) {
+	cfg, err := loadServerConfig("", envMap(map[string]string{
+		"SNAKE_AUTHOR":        "someone-else",
+		"SNAKE_CLAUDIA_COLOR": "#123456",
+		"SNAKE_VERSION":       "v9.9.9",
+	}))
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+
+	claudia := cfg.routes(nil)[0].Info()
+	if claudia.Author != "someone-else" || claudia.Color != "#123456" || claudia.Version != "v9.9.9" {
+		t.Errorf("overrides not applied to claudia: %+v", claudia)
+	}
+	cautious := cfg.routes(nil)[2].Info()
+	if cautious.Author != "someone-else" || cautious.Color != "#3B7DD8" {
+		t.Errorf("per-snake override leaked to another snake: %+v", cautious)
+	}
+}
+
+func TestLoadServerConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snakes.json")
+	err := os.WriteFile(path, []byte(`{"snakes": [
+		{"name": "experimental", "path": "/experimental/", "personality": "aggressive", "color": "#000000"}
+	]}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadServerConfig(path, envMap(nil))
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	routes := cfg.routes(nil)
+	if len(routes) != 1 || routes[0].Path != "/experimental/" || routes[0].ServerID != "battlesnake/dave-smith/experimental" {
+		t.Errorf("unexpected routes from file: %+v", routes)
+	}
+}
+
+func TestServerConfigValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  serverConfig
+	}{
+		{"empty", serverConfig{}},
+		{"missing path", serverConfig{Snakes: []snakeConfig{{Personality: "claudia"}}}},
+		{"unknown personality", serverConfig{Snakes: []snakeConfig{{Path: "/a/", Personality: "reckless"}}}},
+		{"duplicate path", serverConfig{Snakes: []snakeConfig{
+			{Path: "/a/", Personality: "claudia"},
+			{Path: "/a", Personality: "cautious"},
+		}}},
+		{"duplicate server ID", serverConfig{Snakes: []snakeConfig{
+			{Path: "/a/", ServerID: "x", Personality: "claudia"},
+			{Path: "/b/", ServerID: "x", Personality: "cautious"},
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.validate(); err == nil {
+				t.Errorf("expected a validation error")
+			}
+		})
+	}
+}
+
+func TestVersionFromBuildInfo(t *testing.T) {
+	tests := []struct {
+		name string
+		info debug.BuildInfo
+		want string
+	}{
+		{"module version", debug.BuildInfo{Main: debug.Module{Version: "v1.2.3"}}, "v1.2.3"},
+		{"vcs revision", debug.BuildInfo{
+			Main:     debug.Module{Version: "(devel)"},
+			Settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "0123456789abcdef0123"}},
+		}, "0123456789ab"},
+		{"dirty tree", debug.BuildInfo{Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "abc"},
+			{Key: "vcs.modified", Value: "true"},
+		}}, "abc-dirty"},
+		{"nothing stamped", debug.BuildInfo{Main: debug.Module{Version: "(devel)"}}, "dev"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := versionFromBuildInfo(&tt.info); got != tt.want {
+				t.Errorf("got %q, expected %q", got, tt.want)
+			}
+		})
+	}
+}
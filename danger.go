@@ -0,0 +1,109 @@
+package main
+
+const (
+	// dangerHorizon is how many turns ahead the danger map follows opponents
+	dangerHorizon = 3
+)
+
+// opponentReach is one opponent's claim on a cell: the number of turns its
+// head needs to get there, and whether it wins a head-to-head with us there.
+// Ties count as wins, since both snakes die.
+type opponentReach struct {
+	ID    string
+	Turns int
+	Wins  bool
+}
+
+// dangerMap records, for every cell, which opponents can reach it within the
+// horizon and how soon. Opponents walk around bodies, entering a segment's
+// cell only once its tail has passed, but not around each other's future
+// positions.
+type dangerMap struct {
+	horizon int
+	cells   map[Coordinate][]opponentReach
+}
+
+// newDangerMap follows every opponent of state.You for up to horizon turns
+func newDangerMap(state GameState, horizon int) dangerMap {
+	danger := dangerMap{horizon: horizon, cells: make(map[Coordinate][]opponentReach)}
+	free := freeTimes(state, nil)
+	for _, snake := range state.Board.Snakes {
+		if snake.ID == state.You.ID || len(snake.Body) == 0 {
+			continue
+		}
+		wins := snake.Length >= state.You.Length
+		walkReach(snake.Head, 0, horizon, free, state.Board, func(c Coordinate, turns int) {
+			danger.cells[c] = append(danger.cells[c], opponentReach{ID: snake.ID, Turns: turns, Wins: wins})
+		})
+	}
+	return danger
+}
+
+// threat returns the fewest turns in which an opponent that wins a
+// head-to-head reaches c, and false when none can within the horizon
+func (d dangerMap) threat(c Coordinate) (int, bool) {
+	soonest, found := 0, false
+	for _, r := range d.cells[c] {
+		if r.Wins && (!found || r.Turns < soonest) {
+			soonest, found = r.Turns, true
+		}
+	}
+	return soonest, found
+}
+
+// contested reports whether an opponent that wins a head-to-head can be at c
+// no later than we can, given we get there in turns
+func (d dangerMap) contested(c Coordinate, turns int) bool {
+	soonest, found := d.threat(c)
+	return found && soonest <= turns
+}
+
+// dangerScore is the share of the cells we can reach from pos within the
+// horizon that a winning opponent can get to first or at the same time,
+// scaled by profile.DangerPenalty. It is negative, and zero in open space.
+func dangerScore(pos Coordinate, state GameState, danger dangerMap, profile strategyProfile) float64 {
+	if profile.DangerPenalty == 0 || len(state.You.Body) == 0 {
+		return 0
+	}
+	reached := 1
+	contested := 0
+	if danger.contested(pos, 1) {
+		contested++
+	}
+	walkReach(pos, 1, danger.horizon, freeTimes(state, &pos), state.Board, func(c Coordinate, turns int) {
+		reached++
+		if danger.contested(c, turns) {
+			contested++
+		}
+	})
+	return -profile.DangerPenalty * float64(contested) / float64(reached)
+}
+
+// walkReach visits every cell reachable from start, which is reached after
+// startTurns moves, within horizon moves in total and without entering a cell
+// before it is free. visit gets each cell once, with the fewest moves needed.
+func walkReach(start Coordinate, startTurns, horizon int, free map[Coordinate]int, board Board, visit func(Coordinate, int)) {
+	type step struct {
+		pos   Coordinate
+		turns int
+	}
+	seen := map[Coordinate]bool{start: true}
+	queue := []step{{start, startTurns}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current.turns >= horizon {
+			continue
+		}
+		for _, dir := range allDirections {
+			next := getNextPosition(current.pos, dir)
+			turns := current.turns + 1
+			if seen[next] || !onBoard(next, board) || turns < free[next] {
+				continue
+			}
+			seen[next] = true
+			visit(next, turns)
+			queue = append(queue, step{next, turns})
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import "testing"
+
+// A is longer than us and sits two columns to our left
+const dangerBoard = `
+A a a' . . . .
+. . . . . . .
+. . . Y y' . .
+`
+
+func TestDangerMap(t *testing.T) {
+	state, err := parseBoard(dangerBoard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	danger := newDangerMap(state, dangerHorizon)
+
+	tests := []struct {
+		name  string
+		cell  Coordinate
+		turns int
+		found bool
+	}{
+		{"next to its head", Coordinate{X: 0, Y: 1}, 1, true},
+		{"around the corner", Coordinate{X: 1, Y: 1}, 2, true},
+		// Its own neck only frees after two moves
+		{"its neck", Coordinate{X: 1, Y: 2}, 3, true},
+		{"beyond the horizon", Coordinate{X: 6, Y: 2}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			turns, found := danger.threat(tt.cell)
+			if turns != tt.turns || found != tt.found {
+				t.Errorf("threat(%v) = %d, %v, expected %d, %v", tt.cell, turns, found, tt.turns, tt.found)
+			}
+		})
+	}
+
+	reach := danger.cells[Coordinate{X: 0, Y: 0}]
+	if len(reach) != 1 || reach[0].ID != "A" || reach[0].Turns != 2 || !reach[0].Wins {
+		t.Errorf("reach of the corner = %+v", reach)
+	}
+
+	// A shorter opponent still reaches cells, but does not threaten them
+	state.You.Length = 4
+	if _, found := newDangerMap(state, dangerHorizon).threat(Coordinate{X: 0, Y: 1}); found {
+		t.Errorf("a shorter snake should not threaten us")
+	}
+}
+
+func TestDangerScore(t *testing.T) {
+	state, err := parseBoard(dangerBoard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	danger := newDangerMap(state, dangerHorizon)
+	toward := dangerScore(getNextPosition(state.You.Head, "left"), state, danger, defaultProfile)
+	away := dangerScore(getNextPosition(state.You.Head, "right"), state, danger, defaultProfile)
+	if toward >= 0 || away != 0 {
+		t.Errorf("danger toward A = %v, away = %v; expected a penalty only toward A", toward, away)
+	}
+}
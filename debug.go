@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"strings"
+	"time"
+)
+
+// processStarted is when the process started, for the uptime in /debug/runtime
+var processStarted = time.Now()
+
+// registerDebug mounts pprof, a goroutine dump and runtime stats under
+// /debug/, all behind token. Nothing is mounted when token is empty.
+//
+// The token goes in an "Authorization: Bearer <token>" header, or in a
+// ?token= parameter for tools that only take a URL, such as go tool pprof.
+// Importing net/http/pprof also registers its handlers on
+// http.DefaultServeMux, which the server does not serve.
+func registerDebug(mux *http.ServeMux, token string, routes []SnakeRoute) {
+	if token == "" {
+		return
+	}
+	handle := func(path string, handler http.HandlerFunc) {
+		mux.HandleFunc(path, requireToken(token, handler))
+	}
+
+	handle("/debug/pprof/", pprof.Index)
+	handle("/debug/pprof/cmdline", pprof.Cmdline)
+	handle("/debug/pprof/profile", withoutServerTimeout(pprof.Profile))
+	handle("/debug/pprof/symbol", pprof.Symbol)
+	handle("/debug/pprof/trace", withoutServerTimeout(pprof.Trace))
+	handle("/debug/goroutines", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		runtimepprof.Lookup("goroutine").WriteTo(w, 2)
+	})
+	handle("/debug/runtime", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(readRuntimeStats(routes))
+	})
+}
+
+// requireToken rejects requests without the debug token. Accepted requests
+// may stream for longer than the server's write timeout, as CPU profiles and
+// traces do.
+func requireToken(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if given == "" {
+			given = r.URL.Query().Get("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			slog.Warn("Rejected debug request", "request", requestID(r), "path", r.URL.Path)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		http.NewResponseController(w).SetWriteDeadline(time.Time{})
+		next(w, r)
+	}
+}
+
+// withoutServerTimeout hides the http.Server from a profile or trace handler.
+// Older Go releases reject any ?seconds= at or above the server's
+// WriteTimeout, which would cap profiles below ten seconds; requireToken has
+// already lifted the write deadline these handlers would otherwise hit.
+func withoutServerTimeout(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		next(w, r.WithContext(context.WithValue(r.Context(), http.ServerContextKey, nil)))
+	}
+}
+
+// runtimeStats is the /debug/runtime response
+type runtimeStats struct {
+	Version    string         `json:"version"`
+	GoVersion  string         `json:"goVersion"`
+	Uptime     string         `json:"uptime"`
+	Goroutines int            `json:"goroutines"`
+	HeapAlloc  uint64         `json:"heapAllocBytes"`
+	HeapInuse  uint64         `json:"heapInuseBytes"`
+	Sys        uint64         `json:"sysBytes"`
+	NumGC      uint32         `json:"numGC"`
+	PauseTotal string         `json:"gcPauseTotal"`
+	Games      map[string]int `json:"games"`
+}
+
+func readRuntimeStats(routes []SnakeRoute) runtimeStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	games := make(map[string]int, len(routes))
+	for _, snake := range routes {
+		if snake.Sessions != nil {
+			games[snake.ServerID] = snake.Sessions.len()
+		}
+	}
+	return runtimeStats{
+		Version:    buildVersion(),
+		GoVersion:  runtime.Version(),
+		Uptime:     time.Since(processStarted).Round(time.Second).String(),
+		Goroutines: runtime.NumGoroutine(),
+		HeapAlloc:  mem.HeapAlloc,
+		HeapInuse:  mem.HeapInuse,
+		Sys:        mem.Sys,
+		NumGC:      mem.NumGC,
+		PauseTotal: time.Duration(mem.PauseTotalNs).String(),
+		Games:      games,
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDebugDisabledWithoutToken(t *testing.T) {
+	mux := http.NewServeMux()
+	registerDebug(mux, "", nil)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/runtime", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("/debug/runtime returned %d without a token configured, expected 404", rec.Code)
+	}
+}
+
+func TestDebugRequiresToken(t *testing.T) {
+	store := newSessionStore("claudia", defaultSessionTTL, "", nil)
+	store.start(testState(t, "game", 0, "Y"))
+	mux := http.NewServeMux()
+	registerDebug(mux, "s3cret", []SnakeRoute{{ServerID: ServerID, Sessions: store}})
+
+	tests := []struct {
+		name     string
+		url      string
+		header   string
+		expected int
+	}{
+		{"no token", "/debug/runtime", "", http.StatusUnauthorized},
+		{"wrong token", "/debug/runtime", "Bearer guess", http.StatusUnauthorized},
+		{"bearer", "/debug/runtime", "Bearer s3cret", http.StatusOK},
+		{"query", "/debug/goroutines?token=s3cret", "", http.StatusOK},
+		{"pprof", "/debug/pprof/", "Bearer s3cret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			if rec.Code != tt.expected {
+				t.Errorf("%s returned %d, expected %d", tt.url, rec.Code, tt.expected)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/runtime", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	var stats runtimeStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("invalid runtime stats %q: %v", rec.Body, err)
+	}
+	if stats.Goroutines == 0 || stats.Games[ServerID] != 1 {
+		t.Errorf("unexpected runtime stats: %+v", stats)
+	}
+}
+
+func TestDebugProfileOutlastsWriteTimeout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("takes a one second CPU profile")
+	}
+	mux := http.NewServeMux()
+	registerDebug(mux, "s3cret", nil)
+
+	// A profile as long as the write timeout, which the server must not cut
+	// short or refuse
+	server := httptest.NewUnstartedServer(nil)
+	server.Config = newHTTPServer("", mux)
+	server.Config.WriteTimeout = time.Second
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/debug/pprof/profile?seconds=1&token=s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading the profile: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || len(body) == 0 {
+		t.Errorf("profile returned %d with %d bytes: %s", resp.StatusCode, len(body), body)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+// escapeRoute follows our head from pos, reached after one move, without
+// entering a body cell before it clears. It reports whether the head can get
+// to the cell our tail is on now, and how many cells it can reach, up to our
+// length; by then every segment has cleared, so counting further tells us
+// nothing.
+func escapeRoute(pos Coordinate, state GameState) (tail bool, room int) {
+	length := len(state.You.Body)
+	if length == 0 {
+		return false, 0
+	}
+	end := state.You.Body[length-1]
+	if pos == end {
+		return true, 1
+	}
+
+	room = 1
+	walkReach(pos, 1, length+1, freeTimes(state, &pos), state.Board, func(c Coordinate, turns int) {
+		room++
+		if c == end {
+			tail = true
+		}
+	})
+	return tail, min(room, length)
+}
+
+// escapeResult is escapeRoute's answer for one move, kept with the move's
+// score so the board is walked at most once per move each turn
+type escapeResult struct {
+	tail bool
+	room int
+}
+
+func newEscapeResult(pos Coordinate, state GameState) *escapeResult {
+	tail, room := escapeRoute(pos, state)
+	return &escapeResult{tail: tail, room: room}
+}
+
+// open reports whether the route is a way out: our tail is reachable, or
+// there is at least as much room as our length
+func (e *escapeResult) open(state GameState) bool {
+	return e.tail || e.room >= len(state.You.Body)
+}
+
+// applyEscapeCheck demotes candidates with no escape route, as long as one
+// candidate has one. It reuses the route scoreMove found while tail chasing.
+func applyEscapeCheck(explanation *MoveExplanation, state GameState) {
+	escapes := false
+	for i := range explanation.Moves {
+		move := &explanation.Moves[i]
+		if !move.Candidate {
+			continue
+		}
+		if move.escape == nil {
+			move.escape = newEscapeResult(getNextPosition(state.You.Head, move.Move), state)
+		}
+		move.NoEscape = !move.escape.open(state)
+		if !move.NoEscape {
+			escapes = true
+		}
+	}
+	if !escapes {
+		return
+	}
+	for i := range explanation.Moves {
+		move := &explanation.Moves[i]
+		if move.Candidate && move.NoEscape {
+			move.Candidate = false
+			move.Demoted = true
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import "testing"
+
+// Our body wraps a two cell pocket to our left; the tail is far from it
+const pocketBoard = `
+. . . . . .
+y y y y . .
+y . . Y . .
+y y y y y y'
+. . . . . .
+`
+
+func TestEscapeRoute(t *testing.T) {
+	state, err := parseBoard(pocketBoard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head := state.You.Head
+
+	tail, room := escapeRoute(getNextPosition(head, "left"), state)
+	if tail || room != 2 {
+		t.Errorf("into the pocket: tail %v, room %d; expected no tail and 2 cells", tail, room)
+	}
+	if newEscapeResult(getNextPosition(head, "left"), state).open(state) {
+		t.Errorf("the pocket should have no escape route")
+	}
+
+	tail, room = escapeRoute(getNextPosition(head, "right"), state)
+	if !tail || room != len(state.You.Body) {
+		t.Errorf("into the open: tail %v, room %d; expected the tail and %d cells", tail, room, len(state.You.Body))
+	}
+	if !newEscapeResult(getNextPosition(head, "right"), state).open(state) {
+		t.Errorf("the open side should have an escape route")
+	}
+}
+
+func TestEscapeCheckDemotes(t *testing.T) {
+	state, err := parseBoard(pocketBoard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	explanation := explainNextMove(state, moveContext{Profile: defaultProfile})
+	if explanation.Move != "right" {
+		t.Errorf("moved %s, expected right out of the pocket", explanation.Move)
+	}
+	left, _ := explanation.explainMove("left")
+	if !left.NoEscape || !left.Demoted || left.Candidate {
+		t.Errorf("left should be demoted for having no escape: %+v", left)
+	}
+}
+
+func TestEscapeRouteWorkedOutOnce(t *testing.T) {
+	state, err := parseBoard(pocketBoard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head := state.You.Head
+	danger := newDangerMap(state, dangerHorizon)
+	score := func(dir string) moveScore {
+		return scoreMove(getNextPosition(head, dir), state, state.You.Health, state.You.Length, defaultProfile, danger)
+	}
+
+	// The pocket is four cells from our tail, too far to chase it
+	if left := score("left"); left.escape != nil {
+		t.Errorf("tail chasing looked for a route from a cell four away from the tail")
+	}
+	right := score("right")
+	if right.escape == nil || !right.escape.tail || right.TailChasing <= 0 {
+		t.Fatalf("right is next to the tail and should chase it: %+v", right)
+	}
+
+	// The escape check keeps the route tail chasing found
+	explanation := MoveExplanation{Moves: []MoveBreakdown{{Move: "right", Candidate: true, moveScore: right}}}
+	applyEscapeCheck(&explanation, state)
+	if explanation.Moves[0].escape != right.escape || explanation.Moves[0].NoEscape {
+		t.Errorf("the escape check should reuse the route found while scoring")
+	}
+}
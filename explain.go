@@ -0,0 +1,48 @@
+package main
+
+// MoveExplanation records how calculateNextMove arrived at its decision
+type MoveExplanation struct {
+	Move  string          `json:"move"`
+	Moves []MoveBreakdown `json:"moves"`
+	// Phase is the game phase whose profile scored the moves
+	Phase string `json:"phase"`
+	// NoValidMoves is set when every direction failed isValidMove, so the
+	// move is the invalid one most likely to survive
+	NoValidMoves bool `json:"noValidMoves,omitempty"`
+}
+
+// MoveBreakdown is the per-factor score of one direction. The embedded
+// moveScore holds evaluateMove's components; Score is the value compared
+// across directions after the collision risk is applied.
+type MoveBreakdown struct {
+	Move          string  `json:"move"`
+	Valid         bool    `json:"valid"`
+	CollisionRisk float64 `json:"collisionRisk"`
+	moveScore
+	Candidate bool    `json:"candidate"`
+	Score     float64 `json:"score"`
+	// NoEscape is set when the move cuts our head off from our tail and
+	// from enough room for our length (see applyEscapeCheck)
+	NoEscape bool `json:"noEscape,omitempty"`
+	// TrappingReplies counts the combinations of opponent replies after
+	// which this move leaves us no safe follow-up (see applySafetyNet)
+	TrappingReplies int `json:"trappingReplies,omitempty"`
+	// Demoted is set when either check cost the move its candidacy
+	Demoted bool `json:"demoted,omitempty"`
+	// LastResort is set when no move was a candidate and the moves were
+	// ranked by Survival, their estimated chance of living through the turn
+	LastResort bool    `json:"lastResort,omitempty"`
+	Survival   float64 `json:"survival,omitempty"`
+	// legal is set on last resorts the rules simulator allows
+	legal bool
+}
+
+// explainMove returns the breakdown for a single direction
+func (e MoveExplanation) explainMove(direction string) (MoveBreakdown, bool) {
+	for _, move := range e.Moves {
+		if move.Move == direction {
+			return move, true
+		}
+	}
+	return MoveBreakdown{}, false
+}
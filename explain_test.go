@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const explainBoard = `
+health: Y=90 A=90
+. . . . . . .
+. . . . . . .
+. . . . . . .
+. . Y . A a a
+. . y . . . a
+. . y' . . . a'
+. . . . . . .
+`
+
+func TestExplainNextMove(t *testing.T) {
+	state, err := parseBoard(explainBoard)
+	if err != nil {
+		t.Fatalf("parseBoard: %v", err)
+	}
+
+	explanation := explainNextMove(state, moveContext{Profile: defaultProfile})
+	if explanation.Move != calculateNextMove(state) {
+		t.Errorf("explained move %s differs from calculateNextMove", explanation.Move)
+	}
+	if len(explanation.Moves) != 4 {
+		t.Fatalf("expected a breakdown for all four directions, got %d", len(explanation.Moves))
+	}
+
+	down, _ := explanation.explainMove("down")
+	if down.Valid || down.Candidate {
+		t.Errorf("down runs into our own neck and should be invalid: %+v", down)
+	}
+
+	right, _ := explanation.explainMove("right")
+	if !right.Valid || right.Veto != "head-to-head" || right.Total != -1000 {
+		t.Errorf("right is next to a longer head and should be vetoed: %+v", right)
+	}
+	if right.Total != evaluateMove(Coordinate{X: 3, Y: 3}, state, 90, 3) {
+		t.Errorf("breakdown total disagrees with evaluateMove")
+	}
+
+	up, _ := explanation.explainMove("up")
+	want := (up.Base + up.Space + up.TailChasing + up.Aggression + up.Trap + up.Danger + up.Food) * up.SafetyMultiplier
+	if up.Total != want {
+		t.Errorf("components of up do not add up: total %v, expected %v", up.Total, want)
+	}
+	if up.Score != up.Total*(1-up.CollisionRisk) {
+		t.Errorf("score of up does not apply the collision risk: %+v", up)
+	}
+}
+
+func TestExplainEndpoint(t *testing.T) {
+	state, err := parseBoard(explainBoard)
+	if err != nil {
+		t.Fatalf("parseBoard: %v", err)
+	}
+	body, _ := json.Marshal(state)
+
+	mux := http.NewServeMux()
+	registerSnake(mux, defaultServerConfig().routes(nil)[0])
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/claudia/explain", strings.NewReader(string(body))))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	moves := got["moves"].([]any)
+	first := moves[0].(map[string]any)
+	for _, field := range []string{"move", "valid", "collisionRisk", "food", "space", "tailChasing", "aggression", "safetyMultiplier", "score"} {
+		if _, ok := first[field]; !ok {
+			t.Errorf("breakdown is missing %q: %v", field, first)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/claudia/explain", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET returned %d, expected 405", rec.Code)
+	}
+}
+
+// TestExplainDuringMove explains a game while its moves are being played; run
+// with -race, it catches the explanation reading models the moves update
+func TestExplainDuringMove(t *testing.T) {
+	mux := http.NewServeMux()
+	registerSnake(mux, defaultServerConfig().routes(nil)[0])
+	boards := []string{
+		". . . . .\n. . A a a'\n. . . . .\nY y y' . .\n. . . . .",
+		". . . . .\n. A a a' .\n. . . . .\nY y y' . .\n. . . . .",
+	}
+	post := func(endpoint string, turn int) {
+		body, _ := json.Marshal(testState(t, "busy", turn, boards[turn%2]))
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/claudia/"+endpoint, strings.NewReader(string(body))))
+	}
+
+	post("start", 0)
+	var wg sync.WaitGroup
+	for _, endpoint := range []string{"move", "explain", "explain", "explain"} {
+		wg.Add(1)
+		go func(endpoint string) {
+			defer wg.Done()
+			for turn := 0; turn < 100; turn++ {
+				post(endpoint, turn)
+			}
+		}(endpoint)
+	}
+	wg.Wait()
+	post("end", 100)
+}
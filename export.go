@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	exportCellSize   = 24
+	exportFrameDelay = 25  // hundredths of a second per turn
+	exportFinalDelay = 300 // hold the last frame so the ending is visible
+)
+
+var (
+	exportBackground = color.RGBA{R: 0x1e, G: 0x1e, B: 0x24, A: 0xff}
+	exportGridColor  = color.RGBA{R: 0x2c, G: 0x2c, B: 0x34, A: 0xff}
+	exportHazard     = color.RGBA{R: 0x4a, G: 0x3a, B: 0x5a, A: 0xff}
+	exportFood       = color.RGBA{R: 0xff, G: 0x5a, B: 0x5f, A: 0xff}
+)
+
+// exportPalette is used for snakes that have no customized color
+var exportPalette = []color.RGBA{
+	{R: 0x7a, G: 0xbf, B: 0x36, A: 0xff},
+	{R: 0xe8, G: 0x5d, B: 0x4a, A: 0xff},
+	{R: 0x4a, G: 0x90, B: 0xe2, A: 0xff},
+	{R: 0xf5, G: 0xc2, B: 0x42, A: 0xff},
+	{R: 0xb5, G: 0x6c, B: 0xe0, A: 0xff},
+	{R: 0x3c, G: 0xc8, B: 0xc8, A: 0xff},
+	{R: 0xf0, G: 0x8c, B: 0x3c, A: 0xff},
+	{R: 0xdd, G: 0xdd, B: 0xdd, A: 0xff},
+}
+
+// exportRect is a filled rectangle in pixel space; both GIF and SVG frames
+// are drawn from the same list of rectangles
+type exportRect struct {
+	rect  image.Rectangle
+	color color.RGBA
+}
+
+// parseHexColor parses a "#RRGGBB" or "#RGB" customization color
+func parseHexColor(s string) (color.RGBA, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return color.RGBA{}, false
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.RGBA{}, false
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, true
+}
+
+// darken scales a color towards black, used to pick out snake heads
+func darken(c color.RGBA, factor float64) color.RGBA {
+	return color.RGBA{
+		R: uint8(float64(c.R) * factor),
+		G: uint8(float64(c.G) * factor),
+		B: uint8(float64(c.B) * factor),
+		A: c.A,
+	}
+}
+
+// exportSnakeColors picks a color for every snake seen in the game, keeping it
+// stable across frames. Customized colors win; the rest come from exportPalette.
+func exportSnakeColors(frames []GameState) map[string]color.RGBA {
+	colors := make(map[string]color.RGBA)
+	next := 0
+	for _, frame := range frames {
+		for _, snake := range frame.Board.Snakes {
+			if _, ok := colors[snake.ID]; ok {
+				continue
+			}
+			if c, ok := parseHexColor(snake.Customizations.Color); ok {
+				colors[snake.ID] = c
+				continue
+			}
+			colors[snake.ID] = exportPalette[next%len(exportPalette)]
+			next++
+		}
+	}
+	return colors
+}
+
+// exportFrameRects lays out one turn as rectangles, with y flipped so that
+// y=0 is the bottom row as in the Battlesnake coordinate system
+func exportFrameRects(state GameState, colors map[string]color.RGBA) []exportRect {
+	width, height := state.Board.Width, state.Board.Height
+	cell := func(c Coordinate) image.Rectangle {
+		x0 := c.X * exportCellSize
+		y0 := (height - 1 - c.Y) * exportCellSize
+		return image.Rect(x0, y0, x0+exportCellSize, y0+exportCellSize)
+	}
+	inBounds := func(c Coordinate) bool {
+		return c.X >= 0 && c.X < width && c.Y >= 0 && c.Y < height
+	}
+
+	rects := []exportRect{{image.Rect(0, 0, width*exportCellSize, height*exportCellSize), exportGridColor}}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			rects = append(rects, exportRect{cell(Coordinate{X: x, Y: y}).Inset(1), exportBackground})
+		}
+	}
+
+	for _, hazard := range state.Board.Hazards {
+		if inBounds(hazard) {
+			rects = append(rects, exportRect{cell(hazard).Inset(1), exportHazard})
+		}
+	}
+
+	for _, food := range state.Board.Food {
+		if inBounds(food) {
+			rects = append(rects, exportRect{cell(food).Inset(exportCellSize / 3), exportFood})
+		}
+	}
+
+	for _, snake := range state.Board.Snakes {
+		body := colors[snake.ID]
+		for i := len(snake.Body) - 1; i >= 0; i-- {
+			segment := snake.Body[i]
+			if !inBounds(segment) {
+				continue
+			}
+			r := cell(segment).Inset(3)
+			// Bridge to the next segment so the body reads as one piece
+			if i > 0 && inBounds(snake.Body[i-1]) && manhattanDistance(segment, snake.Body[i-1]) == 1 {
+				r = r.Union(cell(snake.Body[i-1]).Inset(3))
+			}
+			rects = append(rects, exportRect{r, body})
+		}
+		if len(snake.Body) > 0 && inBounds(snake.Body[0]) {
+			rects = append(rects, exportRect{cell(snake.Body[0]).Inset(2), darken(body, 0.6)})
+		}
+	}
+
+	return rects
+}
+
+// exportGIF renders a recorded game as an animated GIF, one frame per turn
+func exportGIF(w io.Writer, frames []GameState) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames to export")
+	}
+	if frames[0].Board.Width <= 0 || frames[0].Board.Height <= 0 {
+		return fmt.Errorf("invalid board size %dx%d", frames[0].Board.Width, frames[0].Board.Height)
+	}
+
+	colors := exportSnakeColors(frames)
+	layouts := make([][]exportRect, len(frames))
+	paletteIndex := make(map[color.RGBA]uint8)
+	var palette color.Palette
+	for i, frame := range frames {
+		layouts[i] = exportFrameRects(frame, colors)
+		for _, r := range layouts[i] {
+			if _, ok := paletteIndex[r.color]; ok {
+				continue
+			}
+			if len(palette) == 256 {
+				return fmt.Errorf("too many colors for a GIF palette")
+			}
+			paletteIndex[r.color] = uint8(len(palette))
+			palette = append(palette, r.color)
+		}
+	}
+
+	bounds := image.Rect(0, 0, frames[0].Board.Width*exportCellSize, frames[0].Board.Height*exportCellSize)
+	animation := &gif.GIF{}
+	for i, rects := range layouts {
+		img := image.NewPaletted(bounds, palette)
+		for _, r := range rects {
+			index := paletteIndex[r.color]
+			area := r.rect.Intersect(bounds)
+			for y := area.Min.Y; y < area.Max.Y; y++ {
+				for x := area.Min.X; x < area.Max.X; x++ {
+					img.SetColorIndex(x, y, index)
+				}
+			}
+		}
+		delay := exportFrameDelay
+		if i == len(layouts)-1 {
+			delay = exportFinalDelay
+		}
+		animation.Image = append(animation.Image, img)
+		animation.Delay = append(animation.Delay, delay)
+	}
+
+	return gif.EncodeAll(w, animation)
+}
+
+// exportSVG renders a single turn as a standalone SVG document
+func exportSVG(state GameState, colors map[string]color.RGBA) string {
+	width := state.Board.Width * exportCellSize
+	height := state.Board.Height * exportCellSize
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", width, height, width, height)
+	fmt.Fprintf(&b, "<title>turn %d</title>\n", state.Turn)
+	for _, r := range exportFrameRects(state, colors) {
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="#%02x%02x%02x"/>`+"\n",
+			r.rect.Min.X, r.rect.Min.Y, r.rect.Dx(), r.rect.Dy(), r.color.R, r.color.G, r.color.B)
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// exportSVGFrames writes one SVG file per turn into dir, named by turn number
+func exportSVGFrames(dir string, frames []GameState) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames to export")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	colors := exportSnakeColors(frames)
+	for _, frame := range frames {
+		path := filepath.Join(dir, fmt.Sprintf("turn-%04d.svg", frame.Turn))
+		if err := os.WriteFile(path, []byte(exportSVG(frame, colors)), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"image/color"
+	"image/gif"
+	"strings"
+	"testing"
+)
+
+func exportTestFrames(t *testing.T) []GameState {
+	t.Helper()
+	var frames []GameState
+	for turn, board := range []string{
+		". . * .\n. Y . .\n. y' . A",
+		". Y * .\n. y' . .\n. . . A",
+	} {
+		state, err := parseBoard(board)
+		if err != nil {
+			t.Fatalf("parseBoard: %v", err)
+		}
+		state.Turn = turn
+		for i := range state.Board.Snakes {
+			if state.Board.Snakes[i].ID == "Y" {
+				state.Board.Snakes[i].Customizations.Color = "#7ABF36"
+			}
+		}
+		frames = append(frames, state)
+	}
+	return frames
+}
+
+func TestGameRecordRoundTrip(t *testing.T) {
+	frames := exportTestFrames(t)
+
+	var buf bytes.Buffer
+	if err := writeGameRecord(&buf, frames); err != nil {
+		t.Fatalf("writeGameRecord: %v", err)
+	}
+	read, err := readGameRecord(&buf)
+	if err != nil {
+		t.Fatalf("readGameRecord: %v", err)
+	}
+	if len(read) != 2 || read[1].Turn != 1 || !equalCoordinates(read[1].You.Body, frames[1].You.Body) {
+		t.Errorf("round trip lost data: %+v", read)
+	}
+
+	array, err := readGameRecord(strings.NewReader(`[{"turn": 3}, {"turn": 4}]`))
+	if err != nil || len(array) != 2 || array[1].Turn != 4 {
+		t.Errorf("JSON array form not read: %v %+v", err, array)
+	}
+
+	if _, err := readGameRecord(strings.NewReader("  \n")); err == nil {
+		t.Errorf("expected an error for an empty record")
+	}
+}
+
+func TestExportGIF(t *testing.T) {
+	frames := exportTestFrames(t)
+
+	var buf bytes.Buffer
+	if err := exportGIF(&buf, frames); err != nil {
+		t.Fatalf("exportGIF: %v", err)
+	}
+	animation, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("exported GIF does not decode: %v", err)
+	}
+	if len(animation.Image) != 2 {
+		t.Fatalf("expected 2 frames, got %d", len(animation.Image))
+	}
+
+	// Our body segment at (1,0) in the first frame uses the customized color
+	first := animation.Image[0]
+	x, y := 1*exportCellSize+exportCellSize/2, 2*exportCellSize+exportCellSize/2
+	r, g, b, _ := first.At(x, y).RGBA()
+	if got := (color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 0xff}); got != (color.RGBA{R: 0x7a, G: 0xbf, B: 0x36, A: 0xff}) {
+		t.Errorf("body pixel is %v, expected the customized color", got)
+	}
+}
+
+func TestExportSVG(t *testing.T) {
+	frames := exportTestFrames(t)
+	svg := exportSVG(frames[0], exportSnakeColors(frames))
+	if !strings.HasPrefix(svg, "<svg") || !strings.Contains(svg, `fill="#7abf36"`) {
+		t.Errorf("unexpected SVG output:\n%s", svg)
+	}
+	if !strings.Contains(svg, "<title>turn 0</title>") {
+		t.Errorf("SVG is missing its turn title")
+	}
+}
+
+func TestParseHexColor(t *testing.T) {
+	if c, ok := parseHexColor("#fa0"); !ok || c != (color.RGBA{R: 0xff, G: 0xaa, B: 0x00, A: 0xff}) {
+		t.Errorf("short form parsed as %v %v", c, ok)
+	}
+	if _, ok := parseHexColor("green"); ok {
+		t.Errorf("named colors are not supported")
+	}
+}
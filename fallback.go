@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+)
+
+const (
+	// defaultMoveTimeout is the engine's move timeout when a game sends none
+	defaultMoveTimeout = 500 * time.Millisecond
+	// moveLatencyMargin is kept back from the engine's timeout for the round
+	// trip, so a fallback still arrives in time
+	moveLatencyMargin = 150 * time.Millisecond
+)
+
+// moveDeadline is how long the mover may think before the fallback is sent
+func moveDeadline(state GameState) time.Duration {
+	timeout := defaultMoveTimeout
+	if state.Game.Timeout > 0 {
+		timeout = time.Duration(state.Game.Timeout) * time.Millisecond
+	}
+	if timeout-moveLatencyMargin < timeout/2 {
+		return timeout / 2
+	}
+	return timeout - moveLatencyMargin
+}
+
+// safeFallbackMove picks a legal move with a few constant-time checks, for
+// use when the full strategy fails. It prefers moves that cannot meet an
+// equal or longer head, then moves with the most free neighbors.
+func safeFallbackMove(state GameState) (move string) {
+	defer func() {
+		if recover() != nil {
+			move = "up"
+		}
+	}()
+
+	move = "up"
+	best := -1
+	for _, dir := range []string{"up", "down", "left", "right"} {
+		next := getNextPosition(state.You.Head, dir)
+		if !isValidMove(next, state) {
+			continue
+		}
+
+		score := 0
+		for _, d := range []string{"up", "down", "left", "right"} {
+			if isValidMove(getNextPosition(next, d), state) {
+				score++
+			}
+		}
+		contested := false
+		for _, snake := range state.Board.Snakes {
+			if snake.ID != state.You.ID && snake.Length >= state.You.Length && manhattanDistance(snake.Head, next) == 1 {
+				contested = true
+			}
+		}
+		if !contested {
+			score += 10
+		}
+
+		if score > best {
+			best = score
+			move = dir
+		}
+	}
+	return move
+}
+
+// decideMove runs mover within the game's move deadline. If it panics or
+// overruns, the precomputed fallback is returned along with the reason
+// ("panic" or "deadline"); reason is empty when mover answered in time.
+//
+// An overrunning mover is left to finish in the background, since it cannot
+// be interrupted; its answer is discarded. Movers must not hold the game's
+// session while they think, or the next move would wait for it.
+func decideMove(mover SnakeMoverFunc, state GameState, logger *slog.Logger) (response BattlesnakeMoveResponse, reason string) {
+	fallback := safeFallbackMove(state)
+	deadline := moveDeadline(state)
+
+	type result struct {
+		response BattlesnakeMoveResponse
+		panic    any
+		stack    []byte
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- result{panic: p, stack: debug.Stack()}
+			}
+		}()
+		done <- result{response: mover(state)}
+	}()
+
+	timer := time.NewTimer(deadline)
+	defer timer.Stop()
+
+	select {
+	case r := <-done:
+		if r.panic == nil {
+			return r.response, ""
+		}
+		logger.Error("Move panicked, using fallback move", "panic", fmt.Sprint(r.panic), "move", fallback, "stack", string(r.stack))
+		return moveResponse(fallback), "panic"
+	case <-timer.C:
+		logger.Warn("Move deadline passed, using fallback move", "deadline", deadline, "move", fallback)
+		return moveResponse(fallback), "deadline"
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSafeFallbackMove(t *testing.T) {
+	tests := []struct {
+		name     string
+		board    string
+		expected string
+	}{
+		// Only up is legal
+		{"forced", ". . .\nY y y'\nA a' .", "up"},
+		// Left and right are legal, but right may meet a longer head
+		{"avoid contested cell", ". . . . .\n. . Y . A\n. . y . a\n. . y' . a\n. . . . a'", "left"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, err := parseBoard(tt.board)
+			if err != nil {
+				t.Fatalf("parseBoard: %v", err)
+			}
+			if got := safeFallbackMove(state); got != tt.expected {
+				t.Errorf("safeFallbackMove = %q, expected %q\n%s", got, tt.expected, renderBoard(state, renderOptions{}))
+			}
+		})
+	}
+
+	// An empty state must not panic
+	if got := safeFallbackMove(GameState{}); got != "up" {
+		t.Errorf("safeFallbackMove on an empty state = %q, expected up", got)
+	}
+}
+
+func TestMoveDeadline(t *testing.T) {
+	var state GameState
+	if got := moveDeadline(state); got != 350*time.Millisecond {
+		t.Errorf("default deadline = %v, expected 350ms", got)
+	}
+	state.Game.Timeout = 200
+	if got := moveDeadline(state); got != 100*time.Millisecond {
+		t.Errorf("deadline for a 200ms timeout = %v, expected half of it", got)
+	}
+}
+
+func TestMoveFallback(t *testing.T) {
+	state, err := parseBoard(". . .\nY y y'\nA a' .")
+	if err != nil {
+		t.Fatalf("parseBoard: %v", err)
+	}
+	state.Game.Timeout = 100
+	body, _ := json.Marshal(state)
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(logger)
+
+	tests := []struct {
+		reason string
+		mover  SnakeMoverFunc
+	}{
+		{"panic", func(state GameState) BattlesnakeMoveResponse {
+			var body []Coordinate
+			return moveResponse(moveBetween(body[0], state.You.Head))
+		}},
+		{"deadline", func(state GameState) BattlesnakeMoveResponse {
+			time.Sleep(time.Second)
+			return moveResponse("down")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reason, func(t *testing.T) {
+			serverID := "battlesnake/test/fallback-" + tt.reason
+			labels := append(gameLabelValues(serverID, state), tt.reason)
+
+			started := time.Now()
+			rec := httptest.NewRecorder()
+			SnakeHandlerMove(tt.mover, serverID, nil)(rec, httptest.NewRequest(http.MethodPost, "/move", bytes.NewReader(body)))
+			if elapsed := time.Since(started); elapsed > 500*time.Millisecond {
+				t.Errorf("fallback took %v", elapsed)
+			}
+
+			var move BattlesnakeMoveResponse
+			if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &move) != nil || move.Move != "up" {
+				t.Errorf("got %d %q, expected the only legal move, up", rec.Code, rec.Body)
+			}
+			if got := metrics.fallbackMoves.value(labels...); got != 1 {
+				t.Errorf("%s fallbacks counted = %v, expected 1", tt.reason, got)
+			}
+		})
+	}
+}
+
+func TestOverrunDoesNotBlockNextMove(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	slow := Personality{Name: "slow", Profile: defaultProfile, decide: func(state GameState, mc moveContext) MoveExplanation {
+		if state.Turn == 1 {
+			<-release
+		}
+		return explainNextMove(state, mc)
+	}}
+	mux := http.NewServeMux()
+	registerSnake(mux, slow.route("/slow", ServerID, BattlesnakeInfoResponse{}, newSessionStore("slow", defaultSessionTTL, "", nil)))
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(logger)
+
+	move := func(turn int) time.Duration {
+		state := testState(t, "overrun", turn, ". . . .\n. Y y y'\n. . . .")
+		state.Game.Timeout = 200
+		body, _ := json.Marshal(state)
+		started := time.Now()
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/slow/move", bytes.NewReader(body)))
+		return time.Since(started)
+	}
+
+	deadline := moveDeadline(GameState{Game: Game{Timeout: 200}})
+	if elapsed := move(1); elapsed < deadline {
+		t.Fatalf("turn 1 answered in %v, before the %v deadline it was meant to overrun", elapsed, deadline)
+	}
+	// Turn 1's decision is still running
+	if elapsed := move(2); elapsed >= deadline {
+		t.Errorf("turn 2 took %v, waiting on the overrunning turn 1", elapsed)
+	}
+}
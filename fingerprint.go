@@ -0,0 +1,329 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// fingerprintDecay is applied to a profile's learned counts before each
+	// new game is merged in, so old behavior fades as opponents are updated
+	fingerprintDecay = 0.9
+	// fingerprintSeedObservations caps how many observations a loaded profile
+	// is worth when seeding a game, so in-game learning can still overrule it
+	fingerprintSeedObservations = 30.0
+	// slowLatencyShare is the share of the move timeout at which an
+	// opponent's mean response time makes timeouts likely; slowTimeoutChance
+	// is how likely we then take one to be
+	slowLatencyShare  = 0.8
+	slowTimeoutChance = 0.3
+)
+
+// headToHeadStats counts how an opponent reacted when another head was two
+// cells away, i.e. when both could move onto the same cell next turn
+type headToHeadStats struct {
+	// Threats: the other snake was at least as long, so a collision would
+	// have killed this opponent
+	Threats  int `json:"threats"`
+	Retreats int `json:"retreats"`
+	// Opportunities: the other snake was shorter and could have been killed
+	Opportunities int `json:"opportunities"`
+	Attacks       int `json:"attacks"`
+}
+
+// retreatRate is the share of head-to-head threats the opponent backed away
+// from; it is 0.5 until a threat has been seen
+func (s headToHeadStats) retreatRate() float64 {
+	if s.Threats == 0 {
+		return 0.5
+	}
+	return float64(s.Retreats) / float64(s.Threats)
+}
+
+// attackRate is the share of chances to kill a shorter snake head-on that
+// the opponent moved in on; it is 0.5 until a chance has been seen
+func (s headToHeadStats) attackRate() float64 {
+	if s.Opportunities == 0 {
+		return 0.5
+	}
+	return float64(s.Attacks) / float64(s.Opportunities)
+}
+
+// opponentProfile is what we remember about an opponent across games. Its
+// typical aggression is the model's lift for moves towards other heads.
+type opponentProfile struct {
+	Name           string          `json:"name"`
+	Customizations Customizations  `json:"customizations"`
+	Games          int             `json:"games"`
+	Model          opponentModel   `json:"model"`
+	HeadToHead     headToHeadStats `json:"headToHead"`
+	LatencyTotalMs float64         `json:"latencyTotalMs"`
+	LatencySamples int             `json:"latencySamples"`
+	LastSeen       time.Time       `json:"lastSeen"`
+}
+
+// MeanLatency is the opponent's average response time in milliseconds
+func (p *opponentProfile) MeanLatency() float64 {
+	if p.LatencySamples == 0 {
+		return 0
+	}
+	return p.LatencyTotalMs / float64(p.LatencySamples)
+}
+
+// fingerprintKey identifies an opponent across games. The Battlesnake API
+// does not send a snake's author, so its appearance stands in for it:
+// two authors rarely pick the same name, color, head and tail.
+func fingerprintKey(snake Snake) string {
+	c := snake.Customizations
+	return strings.ToLower(strings.Join([]string{snake.Name, c.Color, c.Head, c.Tail}, "|"))
+}
+
+// fingerprintDB is the on-disk store of opponent profiles, shared by every
+// snake this server hosts
+type fingerprintDB struct {
+	mu       sync.Mutex
+	path     string
+	profiles map[string]*opponentProfile
+	// saving serializes saves, so that a snapshot is never replaced on disk
+	// by an older one
+	saving sync.Mutex
+}
+
+// openFingerprintDB loads the profiles at path; a missing file is an empty
+// database. An empty path disables the database and returns nil.
+func openFingerprintDB(path string) (*fingerprintDB, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	db := &fingerprintDB{path: path, profiles: make(map[string]*opponentProfile)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return db, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &db.profiles); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return db, nil
+}
+
+// lookup returns a copy of the profile for an opponent, if we have met it
+func (db *fingerprintDB) lookup(snake Snake) (opponentProfile, bool) {
+	if db == nil {
+		return opponentProfile{}, false
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	profile, ok := db.profiles[fingerprintKey(snake)]
+	if !ok {
+		return opponentProfile{}, false
+	}
+	return *profile, true
+}
+
+// seedSession loads the profile of every known opponent into a new game:
+// the opponent's model starts from its learned weights instead of the prior
+func (db *fingerprintDB) seedSession(session *gameSession) {
+	if db == nil || len(session.History) == 0 {
+		return
+	}
+	state := session.History[0]
+	for _, snake := range state.Board.Snakes {
+		if snake.ID == state.You.ID {
+			continue
+		}
+		profile, ok := db.lookup(snake)
+		if !ok {
+			continue
+		}
+		log := session.Opponents[snake.ID]
+		if log == nil {
+			continue
+		}
+
+		seed := profile.Model
+		if n := seed.Observations; n > fingerprintSeedObservations {
+			scale := fingerprintSeedObservations / n
+			for f := range seed.Hits {
+				seed.Hits[f] *= scale
+				seed.Expected[f] *= scale
+			}
+			seed.Observations = fingerprintSeedObservations
+		}
+		model := seed
+		log.Model = &model
+		log.Seed = seed
+		log.Profile = &profile
+	}
+}
+
+// recordSession merges what a finished game taught us about each opponent
+// into its profile and saves the database
+func (db *fingerprintDB) recordSession(session *gameSession) error {
+	if db == nil {
+		return nil
+	}
+	frames := session.frames()
+	if len(frames) == 0 {
+		return nil
+	}
+
+	snakes := make(map[string]Snake)
+	for _, frame := range frames {
+		for _, snake := range frame.Board.Snakes {
+			if snake.ID != frame.You.ID {
+				snakes[snake.ID] = snake
+			}
+		}
+	}
+
+	db.mu.Lock()
+	for id, snake := range snakes {
+		log := session.Opponents[id]
+		if log == nil {
+			continue
+		}
+
+		key := fingerprintKey(snake)
+		profile, ok := db.profiles[key]
+		if !ok {
+			profile = &opponentProfile{Name: snake.Name, Customizations: snake.Customizations}
+			db.profiles[key] = profile
+		}
+
+		// Only what was learned this game; the seed came from the profile
+		for f := range profile.Model.Hits {
+			profile.Model.Hits[f] = profile.Model.Hits[f]*fingerprintDecay + log.Model.Hits[f] - log.Seed.Hits[f]
+			profile.Model.Expected[f] = profile.Model.Expected[f]*fingerprintDecay + log.Model.Expected[f] - log.Seed.Expected[f]
+		}
+		profile.Model.Observations = profile.Model.Observations*fingerprintDecay + (log.Model.Observations - log.Seed.Observations)
+
+		h2h := headToHeadFromFrames(frames, id)
+		profile.HeadToHead.Threats += h2h.Threats
+		profile.HeadToHead.Retreats += h2h.Retreats
+		profile.HeadToHead.Opportunities += h2h.Opportunities
+		profile.HeadToHead.Attacks += h2h.Attacks
+
+		for _, frame := range frames {
+			for _, s := range frame.Board.Snakes {
+				if s.ID != id {
+					continue
+				}
+				if ms, err := strconv.ParseFloat(s.Latency, 64); err == nil && ms > 0 {
+					profile.LatencyTotalMs += ms
+					profile.LatencySamples++
+				}
+			}
+		}
+
+		profile.Games++
+		profile.LastSeen = time.Now().UTC()
+	}
+	db.mu.Unlock()
+
+	return db.save()
+}
+
+// headToHeadFromFrames replays a game and counts how the opponent reacted
+// whenever another head was two cells away
+func headToHeadFromFrames(frames []GameState, snakeID string) headToHeadStats {
+	var stats headToHeadStats
+	for i := 1; i < len(frames); i++ {
+		prev, curr := frames[i-1], frames[i]
+		if curr.Turn != prev.Turn+1 {
+			continue
+		}
+
+		var before, after *Snake
+		for j := range prev.Board.Snakes {
+			if prev.Board.Snakes[j].ID == snakeID {
+				before = &prev.Board.Snakes[j]
+			}
+		}
+		for j := range curr.Board.Snakes {
+			if curr.Board.Snakes[j].ID == snakeID {
+				after = &curr.Board.Snakes[j]
+			}
+		}
+		if before == nil || after == nil {
+			continue
+		}
+
+		for _, other := range prev.Board.Snakes {
+			if other.ID == snakeID || manhattanDistance(before.Head, other.Head) != 2 {
+				continue
+			}
+			closer := manhattanDistance(after.Head, other.Head) < 2
+			if other.Length >= before.Length {
+				stats.Threats++
+				if !closer {
+					stats.Retreats++
+				}
+			} else {
+				stats.Opportunities++
+				if closer {
+					stats.Attacks++
+				}
+			}
+		}
+	}
+	return stats
+}
+
+// save writes the database atomically so a crash never leaves it truncated
+func (db *fingerprintDB) save() error {
+	if db == nil {
+		return nil
+	}
+	db.saving.Lock()
+	defer db.saving.Unlock()
+
+	db.mu.Lock()
+	data, err := json.MarshalIndent(db.profiles, "", "  ")
+	db.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(db.path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(db.path), ".fingerprints-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), db.path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// logFingerprintError reports a failure to update the database without
+// failing the game
+func logFingerprintError(gameID string, err error) {
+	if err != nil {
+		slog.Error("Failed to update opponent fingerprints", "game", gameID, "err", err)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFingerprintsCarryAcrossGames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fingerprints.json")
+	db, err := openFingerprintDB(path)
+	if err != nil {
+		t.Fatalf("openFingerprintDB: %v", err)
+	}
+
+	// Larry turns at every chance instead of going straight
+	boards := []string{
+		". . . . . . .\n. . . . . . .\n. . . . . A .\n. . . . . a .\nY . . . . a' .\ny' . . . . . .",
+		". . . . . . .\n. . . . . . .\n. . . . A a .\n. . . . . a' .\nY . . . . . .\ny' . . . . . .",
+		". . . . . . .\n. . . . . . .\n. . . . a a' .\n. . . . A . .\nY . . . . . .\ny' . . . . . .",
+	}
+	// A is Larry, whom we know by his name and appearance
+	larryState := func(gameID string, turn int, board string) GameState {
+		state := testState(t, gameID, turn, board)
+		larry := &state.Board.Snakes[0]
+		larry.Name = "Leaderboard Larry"
+		larry.Latency = "120"
+		larry.Customizations = Customizations{Color: "#123456", Head: "fang", Tail: "bolt"}
+		return state
+	}
+
+	store := newSessionStore("claudia", time.Minute, "", db)
+	store.start(larryState("game-1", 0, boards[0]))
+	for turn, board := range boards {
+		store.move(larryState("game-1", turn, board), nil)
+	}
+	store.end(larryState("game-1", 3, boards[2]))
+
+	reopened, err := openFingerprintDB(path)
+	if err != nil {
+		t.Fatalf("reopening: %v", err)
+	}
+	larry := larryState("x", 0, boards[0]).Board.Snakes[0]
+	profile, ok := reopened.lookup(larry)
+	if !ok {
+		t.Fatalf("no profile saved for %s", larry.Name)
+	}
+	if profile.Games != 1 || profile.Model.Observations != 2 {
+		t.Errorf("profile has %d games and %v observations, expected 1 and 2", profile.Games, profile.Model.Observations)
+	}
+	if profile.MeanLatency() != 120 {
+		t.Errorf("mean latency = %v, expected 120", profile.MeanLatency())
+	}
+
+	// The next game against Larry starts from his profile
+	next := newSessionStore("claudia", time.Minute, "", reopened)
+	session := next.start(larryState("game-2", 0, boards[0]))
+	log := session.Opponents["A"]
+	if log.Profile == nil || log.Model.Observations != 2 || log.Seed.Observations != 2 {
+		t.Fatalf("game 2 was not seeded from the profile: %+v", log)
+	}
+	if log.Model.lift(featureStraight) >= opponentPriorLifts[featureStraight] {
+		t.Errorf("seeded straight lift %v should be below the prior", log.Model.lift(featureStraight))
+	}
+
+	// Only game 2's own learning is merged back
+	next.end(larryState("game-2", 1, boards[0]))
+	profile, _ = reopened.lookup(larry)
+	if profile.Games != 2 || profile.Model.Observations != 2*fingerprintDecay {
+		t.Errorf("after an empty game: %d games and %v observations, expected 2 and %v", profile.Games, profile.Model.Observations, 2*fingerprintDecay)
+	}
+}
+
+func TestFingerprintKeyUsesAppearance(t *testing.T) {
+	a := Snake{Name: "Larry", Customizations: Customizations{Color: "#ABCDEF", Head: "fang"}}
+	b := a
+	b.Customizations.Color = "#abcdef"
+	c := a
+	c.Customizations.Head = "default"
+	if fingerprintKey(a) != fingerprintKey(b) {
+		t.Errorf("color case should not matter")
+	}
+	if fingerprintKey(a) == fingerprintKey(c) {
+		t.Errorf("a different head is a different opponent")
+	}
+}
+
+func TestHeadToHeadFromFrames(t *testing.T) {
+	var frames []GameState
+	for turn, board := range []string{
+		// Our longer snake is two cells from A's head; A backs off
+		". . . . .\nY y y y' .\n. . . . .\nA a' . . .\n. . . . .",
+		". . . . .\nY y y y' .\n. . . . .\na' . . . .\nA . . . .",
+	} {
+		frames = append(frames, testState(t, "h2h", turn, board))
+	}
+
+	stats := headToHeadFromFrames(frames, "A")
+	if stats.Threats != 1 || stats.Retreats != 1 {
+		t.Errorf("stats = %+v, expected one threat, retreated", stats)
+	}
+
+	var db *fingerprintDB
+	if _, ok := db.lookup(Snake{}); ok {
+		t.Errorf("a disabled database knows nobody")
+	}
+	if empty, err := openFingerprintDB(""); empty != nil || err != nil {
+		t.Errorf("an empty path should disable the database")
+	}
+}
+
+func TestHeadToHeadFactor(t *testing.T) {
+	bold := &headToHeadStats{Threats: 4, Retreats: 1, Opportunities: 4, Attacks: 4}
+	tests := []struct {
+		name     string
+		stats    *headToHeadStats
+		wins     bool
+		expected float64
+	}{
+		{"unknown snake", nil, true, 1},
+		{"no history yet", &headToHeadStats{}, false, 1},
+		{"always attacks", bold, true, 2},
+		{"rarely retreats", bold, false, 1.5},
+	}
+	for _, tt := range tests {
+		if got := headToHeadFactor(tt.stats, tt.wins); got != tt.expected {
+			t.Errorf("%s: headToHeadFactor = %v, expected %v", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestProfilesShapePredictions(t *testing.T) {
+	state, err := parseBoard(". . . . .\n. . A . .\n. . a . .\n. . a' . .\nY y' . . .")
+	if err != nil {
+		t.Fatal(err)
+	}
+	state.Game.Timeout = 500
+	straight := Coordinate{X: 2, Y: 4}
+
+	unknown := newOpponentPredictor(state, nil, nil).getPredictions()["A"]
+	slow := &opponentProfile{LatencyTotalMs: 900, LatencySamples: 2, HeadToHead: headToHeadStats{Threats: 2}}
+	known := newOpponentPredictor(state, nil, map[string]*opponentProfile{"A": slow}).getPredictions()["A"]
+
+	if known.HeadToHead == nil || known.HeadToHead.Threats != 2 {
+		t.Errorf("prediction should carry the stored head-to-head stats: %+v", known.HeadToHead)
+	}
+	if known.MoveProbability[straight] <= unknown.MoveProbability[straight] {
+		t.Errorf("a snake near the timeout should be likelier to go straight: %v, was %v",
+			known.MoveProbability[straight], unknown.MoveProbability[straight])
+	}
+	total := 0.0
+	for _, p := range known.MoveProbability {
+		total += p
+	}
+	if total < 0.999 || total > 1.001 {
+		t.Errorf("probabilities sum to %v", total)
+	}
+}
+
+func TestFingerprintSavesInOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fingerprints.json")
+	db, err := openFingerprintDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	board := ". . .\nA a' .\nY y' ."
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			store := newSessionStore("claudia", time.Minute, "", db)
+			state, err := parseBoard(board)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			state.Game.ID = fmt.Sprintf("game-%d", i)
+			store.start(state)
+			store.end(state)
+		}(i)
+	}
+	wg.Wait()
+
+	reopened, err := openFingerprintDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, _ := parseBoard(board)
+	if profile, _ := reopened.lookup(state.Board.Snakes[0]); profile.Games != 8 {
+		t.Errorf("the saved database has %d games, expected all 8", profile.Games)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// checkFuzzedMove fails unless move is a direction, and a legal one whenever
+// state allows a legal move
+func checkFuzzedMove(t *testing.T, state GameState, move string) {
+	t.Helper()
+	if !slices.Contains(allDirections, move) {
+		t.Fatalf("returned %q, expected one of %v", move, allDirections)
+	}
+	legal := legalMoves(state, state.You.ID)
+	if len(legal) > 0 && !slices.Contains(legal, move) {
+		t.Fatalf("returned illegal move %q, legal moves are %v\n%s", move, legal, renderBoard(state, renderOptions{}))
+	}
+}
+
+func FuzzCalculateNextMoveJSON(f *testing.F) {
+	paths, _ := filepath.Glob(filepath.Join("testdata", "scenarios", "*.txt"))
+	for _, path := range paths {
+		if scenario, err := loadScenario(path); err == nil {
+			body, _ := json.Marshal(scenario.State)
+			f.Add(body)
+		}
+	}
+	f.Add([]byte(`{}`))
+	f.Add([]byte(`{"board":{"width":3,"height":3,"snakes":[{"id":"a","body":[]}]},"you":{"id":"a","body":[]}}`))
+	f.Add([]byte(`{"board":{"width":-1,"height":0},"you":{"id":"a","length":5,"body":[{"x":9,"y":9}]}}`))
+	// An empty body used to panic in the tail chasing score
+	f.Add([]byte(`{"board":{"width":5,"height":5},"you":{"id":"a","health":100,"length":5}}`))
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		state, err := unmarshalState(httptest.NewRequest(http.MethodPost, "/move", bytes.NewReader(body)))
+		if err != nil {
+			return
+		}
+		// Even unvalidated input must not panic
+		if move := calculateNextMove(state); !slices.Contains(allDirections, move) {
+			t.Fatalf("returned %q for unvalidated input", move)
+		}
+
+		state, _, err = normalizeState(state, true)
+		if err != nil {
+			return
+		}
+		checkFuzzedMove(t, state, calculateNextMove(state))
+	})
+}
+
+func FuzzCalculateNextMoveBoard(f *testing.F) {
+	f.Add(int64(1), uint8(11), uint8(11), uint8(4), uint8(3), false)
+	f.Add(int64(2), uint8(7), uint8(7), uint8(8), uint8(10), true)
+	f.Add(int64(3), uint8(1), uint8(2), uint8(1), uint8(1), true)
+	f.Add(int64(4), uint8(25), uint8(25), uint8(2), uint8(200), true)
+
+	f.Fuzz(func(t *testing.T, seed int64, width, height, snakes, length uint8, damaged bool) {
+		w, h := int(width%25)+1, int(height%25)+1
+		n, l := int(snakes%8)+1, int(length)%(w*h)+1
+		state := generateState(rand.New(rand.NewSource(seed)), w, h, n, l, damaged, false)
+		if len(state.Board.Snakes) == 0 {
+			return
+		}
+		if _, _, err := normalizeState(state, true); err != nil {
+			t.Fatalf("generated an invalid state: %v", err)
+		}
+		checkFuzzedMove(t, state, calculateNextMove(state))
+	})
+}
@@ -0,0 +1,141 @@
+package main
+
+// opponentTurn is what one opponent did between two consecutive turns
+type opponentTurn struct {
+	// Turn is the turn the move was made from
+	Turn int
+	// Move is the direction the head moved, empty if it could not be told
+	// (a skipped turn or a wrapped board edge)
+	Move        string
+	Ate         bool
+	HealthDelta int
+}
+
+// opponentLog is the running move history of one opponent in a game
+type opponentLog struct {
+	SnakeID string
+	Name    string
+	Turns   []opponentTurn
+	// Model learns the opponent's preferences from its moves
+	Model *opponentModel
+	// Seed is what Model started from when a stored profile was loaded, so
+	// that only this game's learning is saved back
+	Seed opponentModel
+	// Profile is the stored profile of this opponent from earlier games
+	Profile *opponentProfile
+	// Eliminated is set once the snake disappears from the board
+	Eliminated bool
+}
+
+// moveBetween returns the direction that takes a head from one cell to an
+// adjacent one, or "" when the cells are not adjacent
+func moveBetween(from, to Coordinate) string {
+	for _, dir := range []string{"up", "down", "left", "right"} {
+		if getNextPosition(from, dir) == to {
+			return dir
+		}
+	}
+	return ""
+}
+
+// observeOpponents works out what every opponent did between prev and curr
+func observeOpponents(prev, curr GameState) map[string]opponentTurn {
+	observed := make(map[string]opponentTurn)
+	if curr.Turn != prev.Turn+1 {
+		return observed
+	}
+
+	before := make(map[string]Snake, len(prev.Board.Snakes))
+	for _, snake := range prev.Board.Snakes {
+		before[snake.ID] = snake
+	}
+
+	for _, snake := range curr.Board.Snakes {
+		if snake.ID == curr.You.ID {
+			continue
+		}
+		last, ok := before[snake.ID]
+		if !ok {
+			continue
+		}
+		observed[snake.ID] = opponentTurn{
+			Turn:        prev.Turn,
+			Move:        moveBetween(last.Head, snake.Head),
+			Ate:         snake.Length > last.Length,
+			HealthDelta: snake.Health - last.Health,
+		}
+	}
+	return observed
+}
+
+// observeTurn appends the latest turn to the opponent logs; it is called with
+// the session locked after the current state has been added to History
+func (g *gameSession) observeTurn() {
+	if g.Opponents == nil {
+		g.Opponents = make(map[string]*opponentLog)
+	}
+	if len(g.History) == 0 {
+		return
+	}
+	curr := g.History[len(g.History)-1]
+
+	alive := make(map[string]bool, len(curr.Board.Snakes))
+	for _, snake := range curr.Board.Snakes {
+		if snake.ID == curr.You.ID {
+			continue
+		}
+		alive[snake.ID] = true
+		if _, ok := g.Opponents[snake.ID]; !ok {
+			g.Opponents[snake.ID] = &opponentLog{SnakeID: snake.ID, Name: snake.Name, Model: newOpponentModel()}
+		}
+	}
+	for id, log := range g.Opponents {
+		if !alive[id] {
+			log.Eliminated = true
+		}
+	}
+
+	prev, ok := g.previous()
+	if !ok {
+		return
+	}
+	for id, turn := range observeOpponents(prev, curr) {
+		log := g.Opponents[id]
+		log.Turns = append(log.Turns, turn)
+		if turn.Move != "" {
+			log.Model.learnFromTurn(prev, id, turn.Move)
+		}
+	}
+}
+
+// moveContext is what this game and earlier ones taught us about the
+// opponents, for deciding a move with profile. It is called with the session
+// locked and holds copies, so the move can be decided after unlocking while
+// later turns keep learning.
+func (g *gameSession) moveContext(profile strategyProfile) moveContext {
+	return moveContext{Profile: profile, Models: g.opponentModels(), Opponents: g.opponentProfiles()}
+}
+
+// opponentProfiles returns the stored profile of every opponent we had met
+// in earlier games
+func (g *gameSession) opponentProfiles() map[string]*opponentProfile {
+	profiles := make(map[string]*opponentProfile)
+	for id, log := range g.Opponents {
+		if log.Profile != nil {
+			profile := *log.Profile
+			profiles[id] = &profile
+		}
+	}
+	return profiles
+}
+
+// opponentModels returns a copy of the learned model of every opponent in
+// the game
+func (g *gameSession) opponentModels() map[string]*opponentModel {
+	models := make(map[string]*opponentModel, len(g.Opponents))
+	for id, log := range g.Opponents {
+		model := *log.Model
+		models[id] = &model
+	}
+	return models
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestObserveOpponents(t *testing.T) {
+	prev := testState(t, "history", 4, `
+health: A=50 B=60
+. . * . .
+. . A . .
+Y . a . B
+y . a' . b'
+`)
+	curr := testState(t, "history", 5, `
+health: A=100 B=59
+. . A . .
+. . a . .
+. Y a' . .
+. y . . B
+`)
+	curr.Board.Snakes[0].Length = 4 // A ate and grew
+
+	observed := observeOpponents(prev, curr)
+	if _, ok := observed["Y"]; ok {
+		t.Errorf("our own moves should not be logged")
+	}
+
+	a := observed["A"]
+	if a.Move != "up" || !a.Ate || a.HealthDelta != 50 || a.Turn != 4 {
+		t.Errorf("A observed as %+v, expected up, ate, +50 from turn 4", a)
+	}
+	b := observed["B"]
+	if b.Move != "down" || b.Ate || b.HealthDelta != -1 {
+		t.Errorf("B observed as %+v, expected down, no food, -1", b)
+	}
+
+	if got := observeOpponents(prev, testState(t, "history", 7, ". Y\n. y")); len(got) != 0 {
+		t.Errorf("moves across a skipped turn cannot be told: %+v", got)
+	}
+}
+
+func TestSessionTracksOpponents(t *testing.T) {
+	store := newSessionStore("claudia", time.Minute, "", nil)
+	boards := []string{
+		". . . .\nY . . A\ny . . a'",
+		". . . .\nY . A .\ny' . a' .",
+		". . . .\nY A . .\ny' a' . .",
+		". . . .\nY . . .\ny' . . .",
+	}
+
+	store.start(testState(t, "history", 0, boards[0]))
+	for turn, board := range boards {
+		store.move(testState(t, "history", turn, board), nil)
+	}
+
+	session, _ := store.get("history")
+	log := session.Opponents["A"]
+	if log == nil {
+		t.Fatal("no log for opponent A")
+	}
+	if len(log.Turns) != 2 {
+		t.Fatalf("A has %d logged turns, expected 2", len(log.Turns))
+	}
+	if log.Turns[0].Move != "left" || log.Turns[1].Move != "left" {
+		t.Errorf("A's moves = %+v, expected left, left", log.Turns)
+	}
+	if last := log.Turns[len(log.Turns)-1]; last.Turn != 1 {
+		t.Errorf("last move is from turn %d, expected 1", last.Turn)
+	}
+	if !log.Eliminated {
+		t.Errorf("A left the board but is not marked eliminated")
+	}
+}
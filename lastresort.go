@@ -0,0 +1,143 @@
+package main
+
+// rankLastResort picks a move when none is a candidate. Each direction,
+// valid or not, gets its chance of surviving the turn. A move the rules
+// allow whatever the others do (see isLegalMove) beats one that needs
+// another snake to die first; then the most likely survivor wins, then a
+// valid move over an invalid one, then the higher score.
+func rankLastResort(explanation MoveExplanation, state GameState, predictions map[string]PredictionData) MoveExplanation {
+	explanation.NoValidMoves = true
+	var best *MoveBreakdown
+	for i := range explanation.Moves {
+		move := &explanation.Moves[i]
+		move.LastResort = true
+		move.Survival = survivalChance(getNextPosition(state.You.Head, move.Move), state, predictions)
+		move.legal = isLegalMove(state, state.You.ID, move.Move)
+		if move.Valid {
+			explanation.NoValidMoves = false
+		}
+
+		if best == nil || lastResortBetter(move, best) {
+			best = move
+		}
+	}
+	best.Candidate = true
+	explanation.Move = best.Move
+	return explanation
+}
+
+func lastResortBetter(a, b *MoveBreakdown) bool {
+	if a.legal != b.legal {
+		return a.legal
+	}
+	if a.Survival != b.Survival {
+		return a.Survival > b.Survival
+	}
+	if a.Valid != b.Valid {
+		return a.Valid
+	}
+	return a.Score > b.Score
+}
+
+// survivalChance estimates the probability that we live through the turn
+// after moving to pos. Under the standard rules:
+//   - walls, starvation and hazard damage that empties our health are certain
+//     death
+//   - a body segment kills unless its snake is eliminated by a wall or
+//     starvation this turn, since those snakes are removed before collisions
+//     are checked; a tail moves away unless it is stacked after eating
+//   - a head-to-head with an equal or longer snake kills only if it moves to
+//     the same cell, which the predictions put a probability on
+func survivalChance(pos Coordinate, state GameState, predictions map[string]PredictionData) float64 {
+	if !onBoard(pos, state.Board) {
+		return 0
+	}
+	if !survivesHealth(state.You, pos, state) {
+		return 0
+	}
+
+	survival := 1.0
+	for _, snake := range state.Board.Snakes {
+		if blocksCell(snake, pos) {
+			if snake.ID == state.You.ID {
+				return 0
+			}
+			survival *= eliminationChance(snake, state, predictions[snake.ID])
+		}
+	}
+
+	for _, snake := range state.Board.Snakes {
+		if snake.ID == state.You.ID || snake.Length < state.You.Length {
+			continue
+		}
+		survival *= 1 - predictions[snake.ID].MoveProbability[pos]
+	}
+	return survival
+}
+
+// blocksCell reports whether pos will still hold one of snake's segments
+// after it moves: every segment but the tail, and a stacked tail too
+func blocksCell(snake Snake, pos Coordinate) bool {
+	if len(snake.Body) == 0 {
+		return false
+	}
+	for _, segment := range snake.Body[:len(snake.Body)-1] {
+		if segment == pos {
+			return true
+		}
+	}
+	return false
+}
+
+// survivesHealth reports whether snake still has health after moving to pos,
+// counting hazard damage unless pos has food
+func survivesHealth(snake Snake, pos Coordinate, state GameState) bool {
+	for _, food := range state.Board.Food {
+		if food == pos {
+			return true
+		}
+	}
+	health := snake.Health - 1
+	for _, hazard := range state.Board.Hazards {
+		if hazard == pos {
+			health -= state.Game.Ruleset.Settings.HazardDamagePerTurn
+		}
+	}
+	return health > 0
+}
+
+// eliminationChance is the probability that snake leaves the board or runs
+// out of health this turn, given its predicted moves. A snake with no valid
+// moves is assumed to pick any direction but back into its neck.
+func eliminationChance(snake Snake, state GameState, prediction PredictionData) float64 {
+	dies := func(pos Coordinate) bool {
+		return !onBoard(pos, state.Board) || !survivesHealth(snake, pos, state)
+	}
+
+	if len(prediction.MoveProbability) > 0 {
+		chance := 0.0
+		for pos, probability := range prediction.MoveProbability {
+			if dies(pos) {
+				chance += probability
+			}
+		}
+		// Normalized probabilities can sum to a hair over 1
+		return min(chance, 1)
+	}
+
+	options, deaths := 0, 0
+	for _, dir := range allDirections {
+		pos := getNextPosition(snake.Head, dir)
+		if len(snake.Body) > 1 && pos == snake.Body[1] {
+			continue
+		}
+		options++
+		if dies(pos) {
+			deaths++
+		}
+	}
+	if options == 0 {
+		return 1
+	}
+	return float64(deaths) / float64(options)
+}
@@ -0,0 +1,87 @@
+package main
+
+import "testing"
+
+// lastResortBoard has us in the bottom-left corner: the walls are left and
+// down, our neck is up, and A's body is to our right
+const lastResortBoard = `
+y' . . .
+y a' A .
+Y a a .
+`
+
+func TestLastResortIntoStarvingSnake(t *testing.T) {
+	explanation := explainNextMove(testState(t, "last-resort", 0, "health: Y=50 A=1"+lastResortBoard), moveContext{Profile: defaultProfile})
+	if !explanation.NoValidMoves || explanation.Move != "right" {
+		t.Fatalf("expected right into the body of a snake about to starve, got %s (no valid moves: %v)", explanation.Move, explanation.NoValidMoves)
+	}
+	right, _ := explanation.explainMove("right")
+	if !right.LastResort || right.Survival != 1 {
+		t.Errorf("right should be a certain last resort survival: %+v", right)
+	}
+
+	// A healthy A keeps its body, so nothing survives
+	explanation = explainNextMove(testState(t, "last-resort", 0, "health: Y=50 A=50"+lastResortBoard), moveContext{Profile: defaultProfile})
+	for _, move := range explanation.Moves {
+		if move.Survival != 0 {
+			t.Errorf("%s survives with chance %v, expected 0", move.Move, move.Survival)
+		}
+	}
+}
+
+func TestSurvivalChance(t *testing.T) {
+	state, err := parseBoard(". . . .\n. . A a\n. . . a'\nY y y' .")
+	if err != nil {
+		t.Fatalf("parseBoard: %v", err)
+	}
+	state.Board.Hazards = []Coordinate{{X: 0, Y: 1}}
+	state.Game.Ruleset.Settings.HazardDamagePerTurn = 14
+	predictions := map[string]PredictionData{"A": {MoveProbability: map[Coordinate]float64{
+		{X: 2, Y: 3}: 0.7, {X: 1, Y: 2}: 0.2, {X: 2, Y: 1}: 0.1,
+	}}}
+
+	tests := []struct {
+		name     string
+		pos      Coordinate
+		health   int
+		expected float64
+	}{
+		{"wall", Coordinate{X: -1, Y: 0}, 90, 0},
+		{"hazard", Coordinate{X: 0, Y: 1}, 90, 1},
+		{"hazard without the health for it", Coordinate{X: 0, Y: 1}, 15, 0},
+		{"own body", Coordinate{X: 1, Y: 0}, 90, 0},
+		{"own tail", Coordinate{X: 2, Y: 0}, 90, 1},
+		{"head to head A probably avoids", Coordinate{X: 2, Y: 1}, 90, 0.9},
+		{"head to head A probably takes", Coordinate{X: 2, Y: 3}, 90, 0.3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := state
+			s.You.Health = tt.health
+			if got := survivalChance(tt.pos, s, predictions); got < tt.expected-1e-9 || got > tt.expected+1e-9 {
+				t.Errorf("survivalChance = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+
+	// Once stacked after eating, our tail stays put this turn
+	state.You.Body = append(state.You.Body, Coordinate{X: 2, Y: 0})
+	state.Board.Snakes[1] = state.You
+	if got := survivalChance(Coordinate{X: 2, Y: 0}, state, predictions); got != 0 {
+		t.Errorf("stacked tail survival = %v, expected 0", got)
+	}
+}
+
+func TestRankLastResortPrefersValidOnTies(t *testing.T) {
+	explanation := MoveExplanation{Moves: []MoveBreakdown{
+		{Move: "up"},
+		{Move: "down", Valid: true, Score: -50},
+		{Move: "left", Valid: true, Score: 10},
+		{Move: "right"},
+	}}
+	// Every move is off a 0x0 board, so all survive with chance 0
+	ranked := rankLastResort(explanation, GameState{}, nil)
+	if ranked.Move != "left" || ranked.NoValidMoves {
+		t.Errorf("expected the best scoring valid move, left, got %s (no valid moves: %v)", ranked.Move, ranked.NoValidMoves)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// newLogger builds the server's logger. level is debug, info, warn or error
+// (LOG_LEVEL, default info) and format is text or json (LOG_FORMAT, default
+// text).
+func newLogger(w io.Writer, level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if level != "" {
+		if err := lvl.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("invalid LOG_LEVEL %q", level)
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch strings.ToLower(format) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid LOG_FORMAT %q, expected text or json", format)
+	}
+}
+
+// requestID returns the ID a proxy assigned to the request, or a new random
+// one, so every line logged for one request can be found together
+func requestID(r *http.Request) string {
+	for _, header := range []string{"X-Request-Id", "Fly-Request-Id"} {
+		if id := r.Header.Get(header); id != "" {
+			return id
+		}
+	}
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// requestLogger tags log lines with the request and the snake serving it
+func requestLogger(r *http.Request, serverId string) *slog.Logger {
+	return slog.Default().With("request", requestID(r), "server", serverId)
+}
+
+// gameLogger adds the game, turn and snake name from a decoded state
+func gameLogger(logger *slog.Logger, state GameState) *slog.Logger {
+	return logger.With("game", state.Game.ID, "turn", state.Turn, "snake", state.You.Name)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := newLogger(&buf, "warn", "json")
+	if err != nil {
+		t.Fatalf("newLogger: %v", err)
+	}
+	logger.Info("hidden")
+	logger.Warn("shown")
+	if strings.Contains(buf.String(), "hidden") || !strings.Contains(buf.String(), `"msg":"shown"`) {
+		t.Errorf("unexpected output at warn level: %s", buf.String())
+	}
+
+	for _, tt := range []struct{ level, format string }{{"loud", ""}, {"", "xml"}} {
+		if _, err := newLogger(&buf, tt.level, tt.format); err == nil {
+			t.Errorf("newLogger(%q, %q) accepted an invalid setting", tt.level, tt.format)
+		}
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/move", nil)
+	if a, b := requestID(r), requestID(r); a == "" || a == b {
+		t.Errorf("generated request IDs %q and %q should be unique", a, b)
+	}
+	r.Header.Set("Fly-Request-Id", "fly-1")
+	if got := requestID(r); got != "fly-1" {
+		t.Errorf("requestID = %q, expected the Fly-Request-Id header", got)
+	}
+}
+
+func TestMoveLogsGameFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := newLogger(&buf, "info", "json")
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(logger)
+
+	state, err := parseBoard(". . .\n. Y .\n. y' .")
+	if err != nil {
+		t.Fatalf("parseBoard: %v", err)
+	}
+	state.Turn = 7
+	body, _ := json.Marshal(state)
+
+	handler := SnakeHandlerMove(func(GameState) BattlesnakeMoveResponse { return moveResponse("up") }, "test", nil)
+	req := httptest.NewRequest(http.MethodPost, "/move", bytes.NewReader(body))
+	req.Header.Set("X-Request-Id", "req-1")
+	handler(httptest.NewRecorder(), req)
+
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("expected one JSON log line at info level, got %q: %v", buf.String(), err)
+	}
+	expected := map[string]any{"msg": "Moving", "request": "req-1", "game": "scenario", "turn": 7.0, "snake": "Y", "move": "up"}
+	for key, value := range expected {
+		if line[key] != value {
+			t.Errorf("%s = %v, expected %v", key, line[key], value)
+		}
+	}
+}
@@ -1,10 +1,9 @@
 package main
 
-// MoveResponse represents the response structure required by Battlesnake API
-type MoveResponse struct {
-	Move  string `json:"move"`
-	Shout string `json:"shout"`
-}
+import (
+	"log"
+	"os"
+)
 
 // Rest of the code remains the same...
 type Coordinate struct {
@@ -13,6 +12,12 @@ type Coordinate struct {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 	RunServer()
 }
 
@@ -25,7 +30,9 @@ type GameState struct {
 }
 
 type Game struct {
-	ID      string `json:"id"`
+	ID string `json:"id"`
+	// Timeout is how many milliseconds the engine waits for a move
+	Timeout int `json:"timeout"`
 	Ruleset struct {
 		Name     string `json:"name"`
 		Version  string `json:"version"`
@@ -46,12 +53,14 @@ type Board struct {
 }
 
 type Snake struct {
-	ID     string       `json:"id"`
-	Name   string       `json:"name"`
-	Health int          `json:"health"`
-	Body   []Coordinate `json:"body"`
-	Head   Coordinate   `json:"head"`
-	Length int          `json:"length"`
+	ID             string         `json:"id"`
+	Name           string         `json:"name"`
+	Health         int            `json:"health"`
+	Body           []Coordinate   `json:"body"`
+	Head           Coordinate     `json:"head"`
+	Length         int            `json:"length"`
+	Latency        string         `json:"latency"`
+	Customizations Customizations `json:"customizations"`
 }
 
 type BattlesnakeInfoResponse struct {
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// counterVec is a Prometheus counter with labels
+type counterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func (c *counterVec) inc(labelValues ...string) {
+	c.add(1, labelValues...)
+}
+
+func (c *counterVec) add(v float64, labelValues ...string) {
+	key := labelKey(c.labels, labelValues)
+	c.mu.Lock()
+	c.values[key] += v
+	c.mu.Unlock()
+}
+
+// value returns the current count for a set of label values
+func (c *counterVec) value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[labelKey(c.labels, labelValues)]
+}
+
+func (c *counterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, key, formatMetricValue(c.values[key]))
+	}
+}
+
+// histogram is one labeled series of a histogramVec
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// histogramVec is a Prometheus histogram with labels
+type histogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogram
+}
+
+func (h *histogramVec) observe(v float64, labelValues ...string) {
+	key := labelKey(h.labels, labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	series, ok := h.series[key]
+	if !ok {
+		series = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = series
+	}
+	for i, bound := range h.buckets {
+		if v <= bound {
+			series.counts[i]++
+		}
+	}
+	series.count++
+	series.sum += v
+}
+
+// count returns how many observations a series has
+func (h *histogramVec) count(labelValues ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if series, ok := h.series[labelKey(h.labels, labelValues)]; ok {
+		return series.count
+	}
+	return 0
+}
+
+func (h *histogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	for _, key := range sortedKeys(h.series) {
+		series := h.series[key]
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, withLabel(key, "le", formatMetricValue(bound)), series.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, withLabel(key, "le", "+Inf"), series.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, key, formatMetricValue(series.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, key, series.count)
+	}
+}
+
+// labelKey renders label pairs in exposition format, e.g. {snake="a",board="11x11"}
+func labelKey(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		pairs[i] = name + `="` + escapeLabelValue(value) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// withLabel appends one more label pair to a rendered label key
+func withLabel(key, name, value string) string {
+	pair := name + `="` + escapeLabelValue(value) + `"`
+	if key == "" {
+		return "{" + pair + "}"
+	}
+	return strings.TrimSuffix(key, "}") + "," + pair + "}"
+}
+
+func escapeLabelValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+func formatMetricValue(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// gameLabels are the labels every per-game metric carries
+var gameLabels = []string{"snake", "ruleset", "board"}
+
+// serverMetrics is every metric the server exports on /metrics
+type serverMetrics struct {
+	moveDuration  *histogramVec
+	gamesStarted  *counterVec
+	gamesEnded    *counterVec
+	deaths        *counterVec
+	decodeErrors  *counterVec
+	invalidStates *counterVec
+	fallbackMoves *counterVec
+	noValidMoves  *counterVec
+}
+
+func newServerMetrics() *serverMetrics {
+	counter := func(name, help string, labels ...string) *counterVec {
+		return &counterVec{name: name, help: help, labels: labels, values: make(map[string]float64)}
+	}
+	withGame := func(extra ...string) []string {
+		return append(append([]string(nil), gameLabels...), extra...)
+	}
+
+	return &serverMetrics{
+		moveDuration: &histogramVec{
+			name:    "battlesnake_move_duration_seconds",
+			help:    "Time taken to decide a move.",
+			labels:  gameLabels,
+			buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.2, 0.3, 0.4, 0.5, 1},
+			series:  make(map[string]*histogram),
+		},
+		gamesStarted:  counter("battlesnake_games_started_total", "Games started.", gameLabels...),
+		gamesEnded:    counter("battlesnake_games_ended_total", "Games ended, by result (win, loss or draw).", withGame("result")...),
+		deaths:        counter("battlesnake_deaths_total", "Games lost, by inferred cause of death.", withGame("cause")...),
+		decodeErrors:  counter("battlesnake_decode_errors_total", "Requests whose body could not be decoded.", "snake", "endpoint"),
+		invalidStates: counter("battlesnake_invalid_states_total", "Decoded game states that failed validation, by outcome (rejected or repaired).", "snake", "endpoint", "outcome"),
+		fallbackMoves: counter("battlesnake_fallback_moves_total", "Moves taken from a fallback, by reason (high-risk, no-valid-moves, panic or deadline).", withGame("reason")...),
+		noValidMoves:  counter("battlesnake_no_valid_moves_total", "Turns where no move passed isValidMove.", gameLabels...),
+	}
+}
+
+// metrics is the process-wide metrics registry
+var metrics = newServerMetrics()
+
+// write renders every metric in Prometheus text format
+func (m *serverMetrics) write(w io.Writer) {
+	m.moveDuration.write(w)
+	m.gamesStarted.write(w)
+	m.gamesEnded.write(w)
+	m.deaths.write(w)
+	m.decodeErrors.write(w)
+	m.invalidStates.write(w)
+	m.fallbackMoves.write(w)
+	m.noValidMoves.write(w)
+}
+
+// gameLabelValues returns the snake, ruleset and board labels for a game
+func gameLabelValues(snake string, state GameState) []string {
+	return []string{snake, state.Game.Ruleset.Name, fmt.Sprintf("%dx%d", state.Board.Width, state.Board.Height)}
+}
+
+// recordDecision counts the fallbacks an explained move went through
+func (m *serverMetrics) recordDecision(snake string, state GameState, explanation MoveExplanation) {
+	labels := gameLabelValues(snake, state)
+	if explanation.NoValidMoves {
+		m.noValidMoves.inc(labels...)
+		m.fallbackMoves.inc(append(labels, "no-valid-moves")...)
+		return
+	}
+	for _, move := range explanation.Moves {
+		if move.Move == explanation.Move && move.LastResort {
+			m.fallbackMoves.inc(append(labels, "high-risk")...)
+		}
+	}
+}
+
+// recordGameEnd counts a finished game's result and, for a loss, its cause
+func (m *serverMetrics) recordGameEnd(snake string, final GameState, last *GameState) {
+	labels := gameLabelValues(snake, final)
+	result := gameResult(final)
+	m.gamesEnded.inc(append(labels, result)...)
+	if result == "loss" {
+		m.deaths.inc(append(labels, inferDeathCause(final, last))...)
+	}
+}
+
+// gameResult classifies a final state from our point of view
+func gameResult(final GameState) string {
+	alive := false
+	for _, snake := range final.Board.Snakes {
+		if snake.ID == final.You.ID {
+			alive = true
+		}
+	}
+	switch {
+	case len(final.Board.Snakes) == 0:
+		return "draw"
+	case alive:
+		return "win"
+	default:
+		return "loss"
+	}
+}
+
+// inferDeathCause guesses why we were eliminated from the /end state and the
+// last state we moved from. The API does not say, so this is best effort.
+func inferDeathCause(final GameState, last *GameState) string {
+	you := final.You
+	if len(you.Body) == 0 {
+		return "unknown"
+	}
+	head := you.Body[0]
+
+	if head.X < 0 || head.Y < 0 || head.X >= final.Board.Width || head.Y >= final.Board.Height {
+		return "wall"
+	}
+	if you.Health <= 0 {
+		for _, hazard := range final.Board.Hazards {
+			if hazard == head {
+				return "hazard"
+			}
+		}
+		return "starvation"
+	}
+	for _, segment := range you.Body[1:] {
+		if segment == head {
+			return "self-collision"
+		}
+	}
+	for _, snake := range final.Board.Snakes {
+		for i, segment := range snake.Body {
+			if segment != head {
+				continue
+			}
+			if i == 0 {
+				return "head-to-head"
+			}
+			return "body-collision"
+		}
+	}
+	// The winner of a head-to-head is on the final board; an opponent that
+	// died alongside us is only in the last state, one move from our head
+	if last != nil {
+		for _, snake := range last.Board.Snakes {
+			if snake.ID != you.ID && manhattanDistance(snake.Head, head) == 1 {
+				return "head-to-head"
+			}
+		}
+	}
+	return "unknown"
+}
+
+// HandleMetrics serves every metric in Prometheus text format
+func HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	metrics.write(w)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCounterVecWrite(t *testing.T) {
+	c := &counterVec{name: "test_total", help: "Test.", labels: []string{"snake", "cause"}, values: make(map[string]float64)}
+	c.inc("b", "wall")
+	c.inc("a", `say "hi"`)
+	c.add(2, "b", "wall")
+
+	var buf bytes.Buffer
+	c.write(&buf)
+	expected := "# HELP test_total Test.\n# TYPE test_total counter\n" +
+		"test_total{snake=\"a\",cause=\"say \\\"hi\\\"\"} 1\n" +
+		"test_total{snake=\"b\",cause=\"wall\"} 3\n"
+	if buf.String() != expected {
+		t.Errorf("got:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestHistogramVecWrite(t *testing.T) {
+	h := &histogramVec{name: "test_seconds", help: "Test.", labels: []string{"snake"}, buckets: []float64{0.1, 0.5}, series: make(map[string]*histogram)}
+	h.observe(0.05, "a")
+	h.observe(0.3, "a")
+	h.observe(2, "a")
+
+	var buf bytes.Buffer
+	h.write(&buf)
+	for _, line := range []string{
+		`test_seconds_bucket{snake="a",le="0.1"} 1`,
+		`test_seconds_bucket{snake="a",le="0.5"} 2`,
+		`test_seconds_bucket{snake="a",le="+Inf"} 3`,
+		`test_seconds_sum{snake="a"} 2.35`,
+		`test_seconds_count{snake="a"} 3`,
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, buf.String())
+		}
+	}
+}
+
+func TestGameResult(t *testing.T) {
+	you := Snake{ID: "Y"}
+	other := Snake{ID: "A"}
+	tests := []struct {
+		snakes   []Snake
+		expected string
+	}{
+		{[]Snake{you}, "win"},
+		{[]Snake{other}, "loss"},
+		{nil, "draw"},
+	}
+	for _, tt := range tests {
+		state := GameState{You: you, Board: Board{Snakes: tt.snakes}}
+		if got := gameResult(state); got != tt.expected {
+			t.Errorf("gameResult with %d snakes = %q, expected %q", len(tt.snakes), got, tt.expected)
+		}
+	}
+}
+
+func TestInferDeathCause(t *testing.T) {
+	board := Board{Width: 5, Height: 5}
+	snake := func(health int, body ...Coordinate) Snake {
+		return Snake{ID: "Y", Health: health, Head: body[0], Body: body, Length: len(body)}
+	}
+	opponent := Snake{ID: "A", Head: Coordinate{X: 2, Y: 3}, Body: []Coordinate{{X: 2, Y: 3}, {X: 2, Y: 4}}}
+
+	tests := []struct {
+		name     string
+		you      Snake
+		snakes   []Snake
+		hazards  []Coordinate
+		last     *GameState
+		expected string
+	}{
+		{"wall", snake(90, Coordinate{X: -1, Y: 2}, Coordinate{X: 0, Y: 2}), nil, nil, nil, "wall"},
+		{"starvation", snake(0, Coordinate{X: 1, Y: 1}, Coordinate{X: 1, Y: 0}), nil, nil, nil, "starvation"},
+		{"hazard", snake(0, Coordinate{X: 1, Y: 1}, Coordinate{X: 1, Y: 0}), nil, []Coordinate{{X: 1, Y: 1}}, nil, "hazard"},
+		{"self", snake(90, Coordinate{X: 1, Y: 1}, Coordinate{X: 1, Y: 2}, Coordinate{X: 1, Y: 1}), nil, nil, nil, "self-collision"},
+		{"body", snake(90, Coordinate{X: 2, Y: 4}, Coordinate{X: 1, Y: 4}), []Snake{opponent}, nil, nil, "body-collision"},
+		{"head", snake(90, Coordinate{X: 2, Y: 3}, Coordinate{X: 1, Y: 3}), []Snake{opponent}, nil, nil, "head-to-head"},
+		{"mutual head", snake(90, Coordinate{X: 2, Y: 2}, Coordinate{X: 1, Y: 2}), nil, nil, &GameState{Board: Board{Snakes: []Snake{opponent}}}, "head-to-head"},
+		{"unknown", snake(90, Coordinate{X: 2, Y: 2}, Coordinate{X: 1, Y: 2}), nil, nil, nil, "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := board
+			b.Snakes = tt.snakes
+			b.Hazards = tt.hazards
+			if got := inferDeathCause(GameState{You: tt.you, Board: b}, tt.last); got != tt.expected {
+				t.Errorf("inferDeathCause = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	serverID := "battlesnake/test/metrics"
+	mux := http.NewServeMux()
+	registerSnake(mux, personalities["claudia"].route("/m", serverID, BattlesnakeInfoResponse{}, newSessionStore("m", defaultSessionTTL, "", nil)))
+	mux.HandleFunc("/metrics", HandleMetrics)
+
+	state, err := parseBoard(". . .\n. Y .\n. y' .")
+	if err != nil {
+		t.Fatalf("parseBoard: %v", err)
+	}
+	state.Game.Ruleset.Name = "solo"
+	body, _ := json.Marshal(state)
+	labels := gameLabelValues(serverID, state)
+
+	for _, endpoint := range []string{"start", "move", "end"} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/m/"+endpoint, bytes.NewReader(body)))
+	}
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/m/move", strings.NewReader("{")))
+
+	if got := metrics.gamesStarted.value(labels...); got != 1 {
+		t.Errorf("games started = %v, expected 1", got)
+	}
+	if got := metrics.gamesEnded.value(append(labels, "win")...); got != 1 {
+		t.Errorf("games won = %v, expected 1", got)
+	}
+	if got := metrics.moveDuration.count(labels...); got != 1 {
+		t.Errorf("move duration observations = %d, expected 1", got)
+	}
+	if got := metrics.decodeErrors.value(serverID, "move"); got != 1 {
+		t.Errorf("move decode errors = %v, expected 1", got)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	expected := `battlesnake_games_started_total{snake="battlesnake/test/metrics",ruleset="solo",board="3x3"} 1`
+	if !strings.Contains(rec.Body.String(), expected+"\n") {
+		t.Errorf("/metrics is missing %q:\n%s", expected, rec.Body)
+	}
+}
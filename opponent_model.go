@@ -0,0 +1,189 @@
+package main
+
+import "math"
+
+// opponentFeature is a property a candidate opponent move may have
+type opponentFeature int
+
+const (
+	// featureFood: the move gets closer to the nearest food
+	featureFood opponentFeature = iota
+	// featureHungryFood: as featureFood, while the snake's health is low
+	featureHungryFood
+	// featureHead: the move gets closer to the nearest other snake's head
+	featureHead
+	// featureSpace: the move leads to the most open space of the options
+	featureSpace
+	// featureStraight: the move continues in the current direction
+	featureStraight
+	// featureEdge: the move ends on the edge of the board
+	featureEdge
+	numOpponentFeatures
+)
+
+var opponentFeatureNames = [numOpponentFeatures]string{
+	"food", "hungryFood", "head", "space", "straight", "edge",
+}
+
+// opponentFeatures marks which features a candidate move has
+type opponentFeatures [numOpponentFeatures]bool
+
+// opponentPriorLifts is how much more (or less) likely we assume a move with
+// each feature is before seeing the opponent play. They follow the fixed
+// multipliers the predictor used to apply.
+var opponentPriorLifts = [numOpponentFeatures]float64{
+	featureFood:       1.2,
+	featureHungryFood: 2.5,
+	featureHead:       1.1,
+	featureSpace:      1.5,
+	featureStraight:   1.3,
+	featureEdge:       0.8,
+}
+
+// opponentPriorStrength is how many observations the prior is worth
+const opponentPriorStrength = 4.0
+
+// opponentHungryHealth is the health below which food moves count as hungry
+const opponentHungryHealth = 30
+
+// opponentModel learns, per feature, how much more often an opponent picks a
+// move with that feature than it would by choosing uniformly among its legal
+// moves. Each lift is the posterior mean of a Gamma-Poisson model: observed
+// picks of feature moves over the picks expected by chance, smoothed towards
+// the prior lift.
+type opponentModel struct {
+	// Hits counts decisions where the chosen move had the feature
+	Hits [numOpponentFeatures]float64 `json:"hits"`
+	// Expected sums the chance of picking a feature move by luck alone
+	Expected [numOpponentFeatures]float64 `json:"expected"`
+	// Observations counts the decisions the model has learned from; stored
+	// profiles decay it along with Hits and Expected
+	Observations float64 `json:"observations"`
+}
+
+func newOpponentModel() *opponentModel {
+	return &opponentModel{}
+}
+
+// lift returns the current estimate of how strongly f attracts the opponent
+func (m *opponentModel) lift(f opponentFeature) float64 {
+	prior := opponentPriorLifts[f]
+	return (m.Hits[f] + prior*opponentPriorStrength) / (m.Expected[f] + opponentPriorStrength)
+}
+
+// weight is the unnormalized likelihood of a move with the given features
+func (m *opponentModel) weight(features opponentFeatures) float64 {
+	weight := 1.0
+	for f := opponentFeature(0); f < numOpponentFeatures; f++ {
+		if features[f] {
+			weight *= m.lift(f)
+		}
+	}
+	return weight
+}
+
+// observe updates the model from one decision: the features of every legal
+// move and which one the opponent actually took. Features that every option
+// shares (or none has) say nothing about preference and are skipped.
+func (m *opponentModel) observe(candidates []opponentFeatures, chosen int) {
+	if chosen < 0 || chosen >= len(candidates) || len(candidates) < 2 {
+		return
+	}
+
+	for f := opponentFeature(0); f < numOpponentFeatures; f++ {
+		with := 0
+		for _, features := range candidates {
+			if features[f] {
+				with++
+			}
+		}
+		if with == 0 || with == len(candidates) {
+			continue
+		}
+		m.Expected[f] += float64(with) / float64(len(candidates))
+		if candidates[chosen][f] {
+			m.Hits[f]++
+		}
+	}
+	m.Observations++
+}
+
+// opponentMoveFeatures lists a snake's legal moves in state along with their
+// directions and features
+func opponentMoveFeatures(state GameState, snake Snake) ([]Coordinate, []string, []opponentFeatures) {
+	var moves []Coordinate
+	var directions []string
+	for _, dir := range []string{"up", "down", "left", "right"} {
+		nextPos := getNextPosition(snake.Head, dir)
+		if isValidMove(nextPos, state) {
+			moves = append(moves, nextPos)
+			directions = append(directions, dir)
+		}
+	}
+	if len(moves) == 0 {
+		return nil, nil, nil
+	}
+
+	foodDist := func(pos Coordinate) int {
+		best := math.MaxInt
+		for _, food := range state.Board.Food {
+			if d := manhattanDistance(pos, food); d < best {
+				best = d
+			}
+		}
+		return best
+	}
+	headDist := func(pos Coordinate) int {
+		best := math.MaxInt
+		for _, other := range state.Board.Snakes {
+			if other.ID == snake.ID {
+				continue
+			}
+			if d := manhattanDistance(pos, other.Head); d < best {
+				best = d
+			}
+		}
+		return best
+	}
+
+	currentDir := ""
+	if len(snake.Body) > 1 {
+		currentDir = moveBetween(snake.Body[1], snake.Head)
+	}
+
+	spaces := make([]float64, len(moves))
+	maxSpace := 0.0
+	for i, pos := range moves {
+		spaces[i] = evaluateAvailableSpace(pos, state, 4)
+		maxSpace = math.Max(maxSpace, spaces[i])
+	}
+
+	features := make([]opponentFeatures, len(moves))
+	for i, pos := range moves {
+		closerToFood := len(state.Board.Food) > 0 && foodDist(pos) < foodDist(snake.Head)
+		features[i][featureFood] = closerToFood
+		features[i][featureHungryFood] = closerToFood && snake.Health < opponentHungryHealth
+		features[i][featureHead] = len(state.Board.Snakes) > 1 && headDist(pos) < headDist(snake.Head)
+		features[i][featureSpace] = spaces[i] == maxSpace
+		features[i][featureStraight] = currentDir != "" && directions[i] == currentDir
+		features[i][featureEdge] = pos.X == 0 || pos.Y == 0 || pos.X == state.Board.Width-1 || pos.Y == state.Board.Height-1
+	}
+	return moves, directions, features
+}
+
+// learnFromTurn updates a model with the move an opponent made from prev
+func (m *opponentModel) learnFromTurn(prev GameState, snakeID string, move string) {
+	for _, snake := range prev.Board.Snakes {
+		if snake.ID != snakeID {
+			continue
+		}
+		_, directions, features := opponentMoveFeatures(prev, snake)
+		for i, dir := range directions {
+			if dir == move {
+				m.observe(features, i)
+				return
+			}
+		}
+		return
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import "testing"
+
+func TestOpponentModelPrior(t *testing.T) {
+	model := newOpponentModel()
+	for f := opponentFeature(0); f < numOpponentFeatures; f++ {
+		if got := model.lift(f); got != opponentPriorLifts[f] {
+			t.Errorf("untrained %s lift = %v, expected the prior %v", opponentFeatureNames[f], got, opponentPriorLifts[f])
+		}
+	}
+	if got := model.weight(opponentFeatures{}); got != 1 {
+		t.Errorf("a move with no features should weigh 1, got %v", got)
+	}
+}
+
+func TestOpponentModelObserve(t *testing.T) {
+	model := newOpponentModel()
+	straight := opponentFeatures{featureStraight: true}
+	turn := opponentFeatures{featureEdge: true}
+	both := opponentFeatures{featureSpace: true, featureStraight: true}
+
+	// An opponent that always turns towards the edge, never straight on
+	for i := 0; i < 20; i++ {
+		model.observe([]opponentFeatures{straight, turn}, 1)
+	}
+
+	if model.Observations != 20 {
+		t.Errorf("observations = %v, expected 20", model.Observations)
+	}
+	if model.lift(featureStraight) >= opponentPriorLifts[featureStraight] {
+		t.Errorf("straight lift %v did not drop below its prior", model.lift(featureStraight))
+	}
+	if model.lift(featureEdge) <= opponentPriorLifts[featureEdge] {
+		t.Errorf("edge lift %v did not rise above its prior", model.lift(featureEdge))
+	}
+	if model.weight(turn) <= model.weight(straight) {
+		t.Errorf("turning should now outweigh going straight")
+	}
+
+	// Features shared by every option carry no information
+	before := model.Expected[featureSpace]
+	model.observe([]opponentFeatures{both, {featureSpace: true}}, 0)
+	if model.Expected[featureSpace] != before {
+		t.Errorf("a feature every option has should not be learned from")
+	}
+
+	// A forced move carries no information either
+	model.observe([]opponentFeatures{straight}, 0)
+	if model.Observations != 21 {
+		t.Errorf("observations = %v, expected 21 after a forced move", model.Observations)
+	}
+}
+
+func TestOpponentMoveFeatures(t *testing.T) {
+	state, err := parseBoard(`
+health: A=10
+. . . . .
+. * . . .
+. . A . .
+. . a . .
+Y y' a' . .
+`)
+	if err != nil {
+		t.Fatalf("parseBoard: %v", err)
+	}
+
+	_, directions, features := opponentMoveFeatures(state, state.Board.Snakes[0])
+	got := make(map[string]opponentFeatures)
+	for i, dir := range directions {
+		got[dir] = features[i]
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 legal moves, got %v", directions)
+	}
+	if !got["up"][featureStraight] || got["left"][featureStraight] {
+		t.Errorf("only up continues straight: %+v", got)
+	}
+	if !got["left"][featureFood] || !got["left"][featureHungryFood] || got["right"][featureFood] {
+		t.Errorf("left and up approach the food while hungry: %+v", got)
+	}
+}
+
+func TestPredictionsFollowTheModel(t *testing.T) {
+	state, err := parseBoard(`
+. . . . . . .
+. . . . . . .
+. . . . . . .
+. . . A . . .
+. . . a . . .
+. . . a' . . .
+Y y' . . . . .
+`)
+	if err != nil {
+		t.Fatalf("parseBoard: %v", err)
+	}
+	up := Coordinate{X: 3, Y: 4}
+
+	prior := newOpponentPredictor(state, nil, nil).getPredictions()["A"]
+
+	// This opponent has never gone straight when it could turn
+	model := newOpponentModel()
+	for i := 0; i < 30; i++ {
+		model.observe([]opponentFeatures{{featureStraight: true}, {}}, 1)
+	}
+	learned := newOpponentPredictor(state, map[string]*opponentModel{"A": model}, nil).getPredictions()["A"]
+
+	if learned.MoveProbability[up] >= prior.MoveProbability[up] {
+		t.Errorf("going straight should become less likely: prior %v, learned %v",
+			prior.MoveProbability[up], learned.MoveProbability[up])
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+// gamePhase is the stage of a game, which decides how moves are weighed
+type gamePhase int
+
+const (
+	// phaseOpening: the first turns, while every snake is short and the
+	// board is empty; growing matters most
+	phaseOpening gamePhase = iota
+	phaseMidgame
+	// phaseEndgame: three or more snakes on a crowded or lopsided board;
+	// space and safety matter most
+	phaseEndgame
+	// phaseDuel: one opponent left; the longer snake hunts, the shorter one
+	// grows and keeps away
+	phaseDuel
+)
+
+var gamePhaseNames = [...]string{"opening", "midgame", "endgame", "duel"}
+
+func (p gamePhase) String() string {
+	return gamePhaseNames[p]
+}
+
+const (
+	// openingTurns and openingFill bound the opening: it ends at whichever
+	// comes first
+	openingTurns = 20
+	openingFill  = 0.1
+	// endgameFill is the share of the board covered by snakes at which a
+	// multi-snake game becomes an endgame
+	endgameFill = 0.3
+	// endgameLengthGap is the length lead that also makes it one: once a
+	// snake is this far ahead, the game plays like an endgame
+	endgameLengthGap = 6
+	// duelLead is how much longer we must be to hunt in a duel
+	duelLead = 2
+)
+
+// detectPhase works out the phase of a game from the turn, the number of
+// live snakes, how much of the board they fill and the gap in their lengths
+func detectPhase(state GameState) gamePhase {
+	area := state.Board.Width * state.Board.Height
+	cells, shortest, longest := 0, 0, 0
+	for i, snake := range state.Board.Snakes {
+		cells += len(snake.Body)
+		if i == 0 || snake.Length < shortest {
+			shortest = snake.Length
+		}
+		longest = max(longest, snake.Length)
+	}
+	fill := 0.0
+	if area > 0 {
+		fill = float64(cells) / float64(area)
+	}
+
+	switch live := len(state.Board.Snakes); {
+	case live < 2:
+		return phaseMidgame
+	case state.Turn < openingTurns && fill < openingFill:
+		return phaseOpening
+	case live == 2:
+		return phaseDuel
+	case fill >= endgameFill || longest-shortest >= endgameLengthGap:
+		return phaseEndgame
+	default:
+		return phaseMidgame
+	}
+}
+
+// phaseProfile adapts a personality's profile to the phase of the game. The
+// adjustments scale the personality's own weights, so an aggressive snake
+// stays more aggressive than a cautious one in every phase.
+func phaseProfile(base strategyProfile, phase gamePhase, state GameState) strategyProfile {
+	p := base
+	switch phase {
+	case phaseOpening:
+		// Grow while food is close and nobody is long enough to threaten us
+		p.HungryHealth = max(p.HungryHealth, 90)
+		p.Food *= 1.5
+		p.AggressionBonus *= 0.5
+	case phaseEndgame:
+		p.SpaceWeight *= 1.3
+		p.SpaceWeightLong *= 1.3
+		p.RiskThreshold *= 0.8
+	case phaseDuel:
+		longest := 0
+		for _, snake := range state.Board.Snakes {
+			if snake.ID != state.You.ID {
+				longest = max(longest, snake.Length)
+			}
+		}
+		if state.You.Length >= longest+duelLead {
+			// Ahead: cut the opponent off and force the head-to-head
+			p.AggressionBonus *= 2
+			p.DefensiveMultiplier = 1 - (1-p.DefensiveMultiplier)/2
+			p.AvoidFood *= 0.5
+		} else {
+			// Behind or level: keep growing and stay away from its head
+			p.HungryHealth = max(p.HungryHealth, 80)
+			p.Food *= 1.3
+			p.AggressionBonus = 0
+			p.SpaceWeight *= 1.2
+		}
+	}
+	return p
+}
@@ -0,0 +1,65 @@
+package main
+
+import "testing"
+
+func TestDetectPhase(t *testing.T) {
+	tests := []struct {
+		name     string
+		board    benchBoard
+		turn     int
+		expected gamePhase
+	}{
+		{"opening", benchBoard{11, 4, false, false}, 3, phaseOpening},
+		{"opening ends with the turn", benchBoard{11, 4, false, false}, openingTurns, phaseMidgame},
+		{"opening ends when the board fills", benchBoard{7, 4, false, false}, 3, phaseMidgame},
+		{"duel", benchBoard{11, 2, false, false}, 50, phaseDuel},
+		{"crowded endgame", benchBoard{11, 4, true, false}, 150, phaseEndgame},
+		{"solo", benchBoard{11, 1, false, false}, 50, phaseMidgame},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := tt.board.state()
+			state.Turn = tt.turn
+			if got := detectPhase(state); got != tt.expected {
+				t.Errorf("detectPhase = %s, expected %s", got, tt.expected)
+			}
+		})
+	}
+
+	// A big length gap makes a sparse multi-snake game an endgame
+	state := benchBoard{19, 4, false, false}.state()
+	state.Turn = 100
+	state.Board.Snakes[1].Length += endgameLengthGap
+	if got := detectPhase(state); got != phaseEndgame {
+		t.Errorf("detectPhase with a %d length gap = %s, expected endgame", endgameLengthGap, got)
+	}
+}
+
+func TestPhaseProfileDuel(t *testing.T) {
+	state := benchBoard{11, 2, false, false}.state()
+	state.Turn = 50
+
+	state.You.Length = state.Board.Snakes[1].Length + duelLead
+	ahead := phaseProfile(defaultProfile, phaseDuel, state)
+	if ahead.AggressionBonus <= defaultProfile.AggressionBonus {
+		t.Errorf("a longer snake should hunt in a duel: aggression %v", ahead.AggressionBonus)
+	}
+
+	state.You.Length = state.Board.Snakes[1].Length
+	level := phaseProfile(defaultProfile, phaseDuel, state)
+	if level.AggressionBonus != 0 || level.Food <= defaultProfile.Food {
+		t.Errorf("a level snake should grow rather than hunt in a duel: %+v", level)
+	}
+
+	// Personalities keep their character within a phase
+	if phaseProfile(aggressiveProfile, phaseDuel, state).RiskThreshold <= phaseProfile(cautiousProfile, phaseDuel, state).RiskThreshold {
+		t.Errorf("aggressive should tolerate more risk than cautious in a duel")
+	}
+}
+
+func TestExplainReportsPhase(t *testing.T) {
+	state := benchBoard{11, 4, false, false}.state()
+	if got := explainNextMove(state, moveContext{Profile: defaultProfile}).Phase; got != "opening" {
+		t.Errorf("explanation phase = %q, expected opening", got)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// A recorded game is a sequence of game states, one per turn, exactly as they
+// arrived in /move requests. On disk it is stored as JSON Lines (one GameState
+// per line); a plain JSON array of states is accepted as well.
+
+// loadGameRecord reads a recorded game from a file
+func loadGameRecord(path string) ([]GameState, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	frames, err := readGameRecord(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return frames, nil
+}
+
+// readGameRecord decodes a recorded game in either JSON Lines or JSON array form
+func readGameRecord(r io.Reader) ([]GameState, error) {
+	reader := bufio.NewReader(r)
+	start, err := peekNonSpace(reader)
+	if err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("recorded game is empty")
+		}
+		return nil, err
+	}
+
+	var frames []GameState
+	if start == '[' {
+		if err := json.NewDecoder(reader).Decode(&frames); err != nil {
+			return nil, err
+		}
+	} else {
+		decoder := json.NewDecoder(reader)
+		for {
+			var state GameState
+			err := decoder.Decode(&state)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("turn %d: %w", len(frames), err)
+			}
+			frames = append(frames, state)
+		}
+	}
+
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("recorded game is empty")
+	}
+	return frames, nil
+}
+
+// writeGameRecord writes a recorded game as JSON Lines
+func writeGameRecord(w io.Writer, frames []GameState) error {
+	encoder := json.NewEncoder(w)
+	for _, frame := range frames {
+		if err := encoder.Encode(frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		if !bytes.ContainsAny(b, " \t\r\n") {
+			return b[0], nil
+		}
+		if _, err := r.ReadByte(); err != nil {
+			return 0, err
+		}
+	}
+}
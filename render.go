@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// renderOptions controls how renderBoard draws a board
+type renderOptions struct {
+	// ANSI colors each snake and marks hazards with a background color;
+	// without it the board is plain ASCII suitable for log files
+	ANSI bool
+}
+
+const ansiReset = "\x1b[0m"
+
+// snakeColors are cycled through for opponents; we are always green
+var snakeColors = []string{
+	"\x1b[31m", // red
+	"\x1b[34m", // blue
+	"\x1b[33m", // yellow
+	"\x1b[35m", // magenta
+	"\x1b[36m", // cyan
+	"\x1b[37m", // white
+}
+
+const (
+	youColor    = "\x1b[1;32m"
+	foodColor   = "\x1b[1;31m"
+	hazardColor = "\x1b[48;5;236m"
+)
+
+// renderCell is what ends up drawn in one cell of the grid
+type renderCell struct {
+	glyph  byte
+	marker byte
+	color  string
+	food   bool
+	hazard bool
+}
+
+// snakeLetters assigns each snake on the board a letter: we are always 'Y',
+// opponents get A, B, C... in board order, skipping Y
+func snakeLetters(state GameState) map[string]byte {
+	letters := make(map[string]byte, len(state.Board.Snakes))
+	next := byte('A')
+	for _, snake := range state.Board.Snakes {
+		if snake.ID == state.You.ID {
+			letters[snake.ID] = 'Y'
+			continue
+		}
+		if next == 'Y' {
+			next++
+		}
+		if next > 'Z' {
+			letters[snake.ID] = '?'
+			continue
+		}
+		letters[snake.ID] = next
+		next++
+	}
+	return letters
+}
+
+// renderBoard draws a game state as a labeled grid, top row first, using the
+// same glyphs as the scenario format: uppercase heads, lowercase bodies, a
+// trailing ' on tails, '*' for food and '#' for hazards. Without the row
+// labels, plain output can be pasted straight into a scenario file. A legend
+// listing every snake follows the grid.
+func renderBoard(state GameState, opts renderOptions) string {
+	width, height := state.Board.Width, state.Board.Height
+	if width <= 0 || height <= 0 {
+		return fmt.Sprintf("turn %d: empty %dx%d board\n", state.Turn, width, height)
+	}
+
+	grid := make([][]renderCell, height)
+	for y := range grid {
+		grid[y] = make([]renderCell, width)
+	}
+	inBounds := func(c Coordinate) bool {
+		return c.X >= 0 && c.X < width && c.Y >= 0 && c.Y < height
+	}
+
+	for _, hazard := range state.Board.Hazards {
+		if inBounds(hazard) {
+			grid[hazard.Y][hazard.X].hazard = true
+		}
+	}
+	for _, food := range state.Board.Food {
+		if inBounds(food) {
+			grid[food.Y][food.X].food = true
+		}
+	}
+
+	letters := snakeLetters(state)
+	colorIndex := 0
+	colors := make(map[string]string, len(state.Board.Snakes))
+	for _, snake := range state.Board.Snakes {
+		if snake.ID == state.You.ID {
+			colors[snake.ID] = youColor
+			continue
+		}
+		colors[snake.ID] = snakeColors[colorIndex%len(snakeColors)]
+		colorIndex++
+	}
+
+	for _, snake := range state.Board.Snakes {
+		letter := letters[snake.ID]
+		// Draw tail to head so the head wins on stacked segments
+		for i := len(snake.Body) - 1; i >= 0; i-- {
+			segment := snake.Body[i]
+			if !inBounds(segment) {
+				continue
+			}
+			cell := &grid[segment.Y][segment.X]
+			cell.color = colors[snake.ID]
+			cell.marker = 0
+			switch {
+			case i == 0:
+				cell.glyph = letter
+			case i == len(snake.Body)-1:
+				cell.glyph = letter + ('a' - 'A')
+				cell.marker = '\''
+			default:
+				cell.glyph = letter + ('a' - 'A')
+			}
+		}
+	}
+
+	labelWidth := len(fmt.Sprint(height - 1))
+	var b strings.Builder
+	fmt.Fprintf(&b, "turn %d  %s  %dx%d\n", state.Turn, state.Game.Ruleset.Name, width, height)
+
+	for y := height - 1; y >= 0; y-- {
+		fmt.Fprintf(&b, "%*d ", labelWidth, y)
+		for x := 0; x < width; x++ {
+			b.WriteString(formatRenderCell(grid[y][x], opts))
+			if x < width-1 {
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "%*s ", labelWidth, "")
+	for x := 0; x < width; x++ {
+		fmt.Fprintf(&b, "%-3d", x%100)
+	}
+	b.WriteString("\n")
+
+	for _, snake := range state.Board.Snakes {
+		name := snake.Name
+		if snake.ID == state.You.ID {
+			name += " (you)"
+		}
+		letter := string(letters[snake.ID])
+		if opts.ANSI {
+			letter = colors[snake.ID] + letter + ansiReset
+		}
+		fmt.Fprintf(&b, "%s %s health=%d length=%d\n", letter, name, snake.Health, snake.Length)
+	}
+
+	return b.String()
+}
+
+// formatRenderCell returns the two characters drawn for a cell
+func formatRenderCell(cell renderCell, opts renderOptions) string {
+	glyph := byte('.')
+	marker := byte(' ')
+	color := ""
+
+	switch {
+	case cell.glyph != 0:
+		glyph = cell.glyph
+		color = cell.color
+		if cell.marker != 0 {
+			marker = cell.marker
+		} else if cell.food {
+			marker = '*'
+		} else if cell.hazard {
+			marker = '#'
+		}
+	case cell.food:
+		glyph = '*'
+		color = foodColor
+		if cell.hazard {
+			marker = '#'
+		}
+	case cell.hazard:
+		glyph = '#'
+	}
+
+	text := string([]byte{glyph, marker})
+	if !opts.ANSI {
+		return text
+	}
+
+	if cell.hazard {
+		color = hazardColor + color
+	}
+	if color == "" {
+		return text
+	}
+	return color + text + ansiReset
+}
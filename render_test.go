@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderBoardRoundTrip(t *testing.T) {
+	board := `
+health: Y=54 A=90
+# . * . .
+. Y* . . .
+. y y' A .
+. . . a a'
+`
+	state, err := parseBoard(board)
+	if err != nil {
+		t.Fatalf("parseBoard: %v", err)
+	}
+
+	rendered := renderBoard(state, renderOptions{})
+	lines := strings.Split(rendered, "\n")
+	if !strings.HasPrefix(lines[0], "turn 0") {
+		t.Errorf("missing header line: %q", lines[0])
+	}
+
+	// Strip the row labels and parse the grid back
+	var grid []string
+	for _, line := range lines[1 : 1+state.Board.Height] {
+		label, row, _ := strings.Cut(line, " ")
+		if label == "" {
+			t.Fatalf("row without a label: %q", line)
+		}
+		grid = append(grid, row)
+	}
+	reparsed, err := parseBoard("health: Y=54 A=90\n" + strings.Join(grid, "\n"))
+	if err != nil {
+		t.Fatalf("rendered grid does not parse: %v\n%s", err, rendered)
+	}
+
+	if !equalCoordinates(reparsed.You.Body, state.You.Body) {
+		t.Errorf("you = %v after round trip, expected %v", reparsed.You.Body, state.You.Body)
+	}
+	if !equalCoordinates(reparsed.Board.Snakes[0].Body, state.Board.Snakes[0].Body) {
+		t.Errorf("opponent = %v after round trip, expected %v", reparsed.Board.Snakes[0].Body, state.Board.Snakes[0].Body)
+	}
+	if len(reparsed.Board.Food) != len(state.Board.Food) || len(reparsed.Board.Hazards) != len(state.Board.Hazards) {
+		t.Errorf("food or hazards lost in round trip:\n%s", rendered)
+	}
+
+	if !strings.Contains(rendered, "Y Y (you) health=54 length=3") {
+		t.Errorf("legend is missing us:\n%s", rendered)
+	}
+	if !strings.Contains(rendered, "A A health=90 length=3") {
+		t.Errorf("legend is missing the opponent:\n%s", rendered)
+	}
+}
+
+func TestRenderBoardANSI(t *testing.T) {
+	state, err := parseBoard("Y y *")
+	if err != nil {
+		t.Fatalf("parseBoard: %v", err)
+	}
+
+	plain := renderBoard(state, renderOptions{})
+	if strings.Contains(plain, "\x1b[") {
+		t.Errorf("plain rendering contains escape codes: %q", plain)
+	}
+	colored := renderBoard(state, renderOptions{ANSI: true})
+	if !strings.Contains(colored, youColor+"Y ") {
+		t.Errorf("our head is not colored: %q", colored)
+	}
+}
+
+func TestRenderBoardEmpty(t *testing.T) {
+	if got := renderBoard(GameState{}, renderOptions{}); !strings.Contains(got, "empty 0x0 board") {
+		t.Errorf("unexpected rendering of an empty board: %q", got)
+	}
+}
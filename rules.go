@@ -0,0 +1,182 @@
+package main
+
+// This file is a small simulator of the standard Battlesnake rules, used to
+// check moves and replay games offline. It follows the official rules'
+// order within a turn: move, reduce health, apply hazard damage, feed, then
+// eliminate. Food never spawns, so a simulated game is deterministic.
+
+import "slices"
+
+var allDirections = []string{"up", "down", "left", "right"}
+
+// isLegalMove reports whether moving snakeID in direction keeps it on the
+// board and out of every body, whatever the other snakes do. Tails move out
+// of the way unless the snake has just eaten (its last two segments are
+// stacked). A body whose snake is certain to leave the board or starve,
+// whichever way it goes, is removed before collisions are judged and does not
+// block. A body whose snake only might be eliminated still blocks; weighing
+// that chance is left to survivalChance. Head-to-head collisions are not
+// counted.
+func isLegalMove(state GameState, snakeID, direction string) bool {
+	var snake *Snake
+	for i := range state.Board.Snakes {
+		if state.Board.Snakes[i].ID == snakeID {
+			snake = &state.Board.Snakes[i]
+		}
+	}
+	if snake == nil || len(snake.Body) == 0 {
+		return false
+	}
+
+	next := getNextPosition(snake.Body[0], direction)
+	if !onBoard(next, state.Board) {
+		return false
+	}
+	for _, other := range state.Board.Snakes {
+		if blocksCell(other, next) && (other.ID == snakeID || !eliminatedFirst(other, state)) {
+			return false
+		}
+	}
+	return true
+}
+
+// eliminatedFirst reports whether snake leaves the board or starves whichever
+// way it moves this turn, so its body is gone before collisions are judged
+func eliminatedFirst(snake Snake, state GameState) bool {
+	if len(snake.Body) == 0 {
+		return true
+	}
+	for _, dir := range allDirections {
+		pos := getNextPosition(snake.Body[0], dir)
+		if onBoard(pos, state.Board) && survivesHealth(snake, pos, state) {
+			return false
+		}
+	}
+	return true
+}
+
+// legalMoves returns every direction isLegalMove accepts for snakeID
+func legalMoves(state GameState, snakeID string) []string {
+	var moves []string
+	for _, dir := range allDirections {
+		if isLegalMove(state, snakeID, dir) {
+			moves = append(moves, dir)
+		}
+	}
+	return moves
+}
+
+func onBoard(c Coordinate, board Board) bool {
+	return c.X >= 0 && c.Y >= 0 && c.X < board.Width && c.Y < board.Height
+}
+
+// stepState plays one turn: every snake moves in the direction moves gives
+// it, or keeps going straight when it has none. Eliminated snakes are removed
+// from the board; You is updated from the board, or left as it was when we
+// were eliminated.
+func stepState(state GameState, moves map[string]string) GameState {
+	next := state
+	next.Turn++
+	next.Board.Food = append([]Coordinate(nil), state.Board.Food...)
+	next.Board.Snakes = make([]Snake, 0, len(state.Board.Snakes))
+
+	// Move
+	for _, snake := range state.Board.Snakes {
+		if len(snake.Body) == 0 {
+			continue
+		}
+		dir, ok := moves[snake.ID]
+		if !ok {
+			dir = "up"
+			if len(snake.Body) > 1 {
+				if straight := moveBetween(snake.Body[1], snake.Body[0]); straight != "" {
+					dir = straight
+				}
+			}
+		}
+		head := getNextPosition(snake.Body[0], dir)
+		body := make([]Coordinate, 0, len(snake.Body)+1)
+		body = append(body, head)
+		body = append(body, snake.Body[:len(snake.Body)-1]...)
+		snake.Body = body
+		snake.Head = head
+		snake.Health--
+		next.Board.Snakes = append(next.Board.Snakes, snake)
+	}
+
+	// Hazards, then food, which cancels hazard damage
+	damage := state.Game.Ruleset.Settings.HazardDamagePerTurn
+	for i := range next.Board.Snakes {
+		snake := &next.Board.Snakes[i]
+		if slices.Contains(next.Board.Food, snake.Head) {
+			continue
+		}
+		for _, hazard := range state.Board.Hazards {
+			if hazard == snake.Head {
+				snake.Health -= damage
+			}
+		}
+	}
+	var remaining []Coordinate
+	for _, food := range next.Board.Food {
+		eaten := false
+		for i := range next.Board.Snakes {
+			snake := &next.Board.Snakes[i]
+			if snake.Head == food {
+				snake.Health = 100
+				snake.Body = append(snake.Body, snake.Body[len(snake.Body)-1])
+				eaten = true
+			}
+		}
+		if !eaten {
+			remaining = append(remaining, food)
+		}
+	}
+	next.Board.Food = remaining
+	for i := range next.Board.Snakes {
+		next.Board.Snakes[i].Length = len(next.Board.Snakes[i].Body)
+	}
+
+	// Eliminate walls and starvation first; those snakes are removed before
+	// collisions are judged, so running into their bodies is safe
+	var standing []Snake
+	for _, snake := range next.Board.Snakes {
+		if snake.Health > 0 && onBoard(snake.Head, next.Board) {
+			standing = append(standing, snake)
+		}
+	}
+	var alive []Snake
+	for _, snake := range standing {
+		if !collided(snake, standing) {
+			alive = append(alive, snake)
+		}
+	}
+	next.Board.Snakes = alive
+
+	for _, snake := range alive {
+		if snake.ID == state.You.ID {
+			next.You = snake
+		}
+	}
+	return next
+}
+
+// collided reports whether snake's head hit a body, or lost a head-to-head,
+// among the snakes still standing after every snake has moved
+func collided(snake Snake, snakes []Snake) bool {
+	for _, other := range snakes {
+		for i, segment := range other.Body {
+			if segment != snake.Head {
+				continue
+			}
+			if i > 0 {
+				return true
+			}
+			// Head to head: the shorter snake dies, or both when equal
+			if other.ID != snake.ID && other.Length >= snake.Length {
+				return true
+			}
+		}
+	}
+	return false
+}
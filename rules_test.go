@@ -0,0 +1,110 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLegalMoves(t *testing.T) {
+	tests := []struct {
+		name     string
+		board    string
+		expected []string
+	}{
+		{"walls and neck", ". . .\n. . .\nY y y'", []string{"up"}},
+		// Tails move out of the way, so following one is legal
+		{"chase tail", ". . .\n. Y y\n. y' y", []string{"up", "down", "left"}},
+		{"opponent body", ". A .\n. a Y\n. a' y'", []string{"up", "down"}},
+		// A starves whichever way it goes, so its body is gone before we arrive
+		{"starving opponent body", "health: A=1\n. A .\n. a Y\n. a' y'", []string{"up", "down", "left"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, err := parseBoard(tt.board)
+			if err != nil {
+				t.Fatalf("parseBoard: %v", err)
+			}
+			if got := legalMoves(state, "Y"); !slices.Equal(got, tt.expected) {
+				t.Errorf("legalMoves = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+
+	// A stacked tail stays put for a turn after eating
+	state, err := parseBoard(". . .\n. Y y\n. y' y")
+	if err != nil {
+		t.Fatalf("parseBoard: %v", err)
+	}
+	state.Board.Snakes[0].Body = append(state.Board.Snakes[0].Body, Coordinate{X: 1, Y: 0})
+	if isLegalMove(state, "Y", "down") {
+		t.Errorf("moved onto a stacked tail")
+	}
+}
+
+func TestStepState(t *testing.T) {
+	state, err := parseBoard(". * . .\n. Y . .\n. y A a\n. y' . a'")
+	if err != nil {
+		t.Fatalf("parseBoard: %v", err)
+	}
+
+	next := stepState(state, map[string]string{"Y": "up", "A": "up"})
+	if next.Turn != state.Turn+1 {
+		t.Errorf("turn = %d, expected %d", next.Turn, state.Turn+1)
+	}
+	if len(next.Board.Food) != 0 || next.You.Health != 100 || next.You.Length != 4 {
+		t.Errorf("eating: food %v, health %d, length %d", next.Board.Food, next.You.Health, next.You.Length)
+	}
+	if next.You.Body[2] != (Coordinate{X: 1, Y: 1}) || next.You.Body[3] != next.You.Body[2] {
+		t.Errorf("grown body should stack its tail: %v", next.You.Body)
+	}
+
+	// A was given no move and kept going straight
+	for _, snake := range next.Board.Snakes {
+		if snake.ID == "A" && snake.Head != (Coordinate{X: 2, Y: 2}) {
+			t.Errorf("A moved to %v, expected straight up to (2,2)", snake.Head)
+		}
+	}
+}
+
+func TestStepStateEliminations(t *testing.T) {
+	board := ". . . . .\n. Y . A .\n. y . a .\n. y' . a' ."
+	tests := []struct {
+		name     string
+		moves    map[string]string
+		grow     bool
+		survivor []string
+	}{
+		{"equal head to head", map[string]string{"Y": "right", "A": "left"}, false, nil},
+		{"longer wins head to head", map[string]string{"Y": "right", "A": "left"}, true, []string{"Y"}},
+		{"self collision", map[string]string{"Y": "left", "A": "down"}, false, []string{"Y"}},
+		{"both survive", map[string]string{"Y": "up", "A": "up"}, false, []string{"A", "Y"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, err := parseBoard(board)
+			if err != nil {
+				t.Fatalf("parseBoard: %v", err)
+			}
+			if tt.grow {
+				y := &state.Board.Snakes[1]
+				y.Body = append(y.Body, y.Body[len(y.Body)-1])
+				y.Length = len(y.Body)
+			}
+
+			next := stepState(state, tt.moves)
+			var survivors []string
+			for _, snake := range next.Board.Snakes {
+				survivors = append(survivors, snake.ID)
+			}
+			if !slices.Equal(survivors, tt.survivor) {
+				t.Errorf("survivors %v, expected %v", survivors, tt.survivor)
+			}
+		})
+	}
+
+	// Leaving the board eliminates
+	state, _ := parseBoard(". Y\n. y'")
+	if next := stepState(state, map[string]string{"Y": "right"}); len(next.Board.Snakes) != 0 {
+		t.Errorf("a snake off the board survived")
+	}
+}
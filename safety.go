@@ -0,0 +1,121 @@
+package main
+
+import "slices"
+
+const (
+	// replyReach is how close an opponent's head must be to our next head for
+	// its reply to matter: within two cells it can take our next cell or one
+	// of the cells we would leave through
+	replyReach = 2
+	// maxReplySnakes caps how many of those opponents have every reply tried,
+	// so a crowded board costs at most 3^maxReplySnakes simulated turns per
+	// move; the rest are treated like opponents further away
+	maxReplySnakes = 3
+)
+
+// applySafetyNet checks every candidate two plies deep: for each combination
+// of replies by the opponents near our next head, we must still have a move
+// that is legal and keeps us fed enough to live. Candidates that some reply
+// combination traps are demoted below those no reply can trap; when every
+// candidate can be trapped, the ones trapped by the fewest combinations stay
+// candidates.
+func applySafetyNet(explanation *MoveExplanation, state GameState) {
+	fewest := -1
+	for i := range explanation.Moves {
+		move := &explanation.Moves[i]
+		if !move.Candidate {
+			continue
+		}
+		move.TrappingReplies = trappingReplies(move.Move, state)
+		if fewest < 0 || move.TrappingReplies < fewest {
+			fewest = move.TrappingReplies
+		}
+	}
+
+	for i := range explanation.Moves {
+		move := &explanation.Moves[i]
+		if move.Candidate && move.TrappingReplies > fewest {
+			move.Candidate = false
+			move.Demoted = true
+		}
+	}
+}
+
+// trappingReplies plays our move against every combination of replies by the
+// nearest maxReplySnakes opponents within replyReach of where it takes us,
+// and counts the combinations after which we are dead or have no safe
+// follow-up. Every other opponent takes its first legal move.
+func trappingReplies(direction string, state GameState) int {
+	if len(state.You.Body) == 0 {
+		return 0
+	}
+	next := getNextPosition(state.You.Head, direction)
+
+	moves := map[string]string{state.You.ID: direction}
+	options := map[string][]string{}
+	for _, snake := range state.Board.Snakes {
+		if snake.ID == state.You.ID || len(snake.Body) == 0 {
+			continue
+		}
+		// A snake without legal moves dies whichever way it goes; it is left
+		// to keep going straight
+		if legal := legalMoves(state, snake.ID); len(legal) > 0 {
+			moves[snake.ID] = legal[0]
+			options[snake.ID] = legal
+		}
+	}
+	near := nearestRepliers(next, state, options)
+
+	trapped := 0
+	var play func(i int)
+	play = func(i int) {
+		if i == len(near) {
+			if !hasSafeFollowUp(stepState(state, moves), state.You.ID) {
+				trapped++
+			}
+			return
+		}
+		for _, reply := range options[near[i]] {
+			moves[near[i]] = reply
+			play(i + 1)
+		}
+	}
+	play(0)
+	return trapped
+}
+
+// nearestRepliers returns the opponents with legal moves whose heads are
+// within replyReach of next, nearest first and at most maxReplySnakes of them
+func nearestRepliers(next Coordinate, state GameState, options map[string][]string) []string {
+	var near []Snake
+	for _, snake := range state.Board.Snakes {
+		if _, ok := options[snake.ID]; ok && manhattanDistance(next, snake.Head) <= replyReach {
+			near = append(near, snake)
+		}
+	}
+	slices.SortStableFunc(near, func(a, b Snake) int {
+		return manhattanDistance(next, a.Head) - manhattanDistance(next, b.Head)
+	})
+
+	ids := make([]string, 0, min(len(near), maxReplySnakes))
+	for _, snake := range near[:min(len(near), maxReplySnakes)] {
+		ids = append(ids, snake.ID)
+	}
+	return ids
+}
+
+// hasSafeFollowUp reports whether snakeID is still on the board and has a
+// legal move that does not starve it
+func hasSafeFollowUp(state GameState, snakeID string) bool {
+	for _, snake := range state.Board.Snakes {
+		if snake.ID != snakeID {
+			continue
+		}
+		for _, dir := range legalMoves(state, snakeID) {
+			if survivesHealth(snake, getNextPosition(snake.Head, dir), state) {
+				return true
+			}
+		}
+	}
+	return false
+}
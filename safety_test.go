@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+// In the top-left corner our only way out of (0, 2) is (0, 1), which A's only
+// legal move takes
+const cornerPocket = `
+. Y y
+. . y'
+A a a'
+`
+
+func TestTrappingReplies(t *testing.T) {
+	state, err := parseBoard(cornerPocket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := trappingReplies("left", state); got != 1 {
+		t.Errorf("left into the corner: %d trapping replies, expected 1", got)
+	}
+	if got := trappingReplies("down", state); got != 0 {
+		t.Errorf("down: %d trapping replies, expected 0", got)
+	}
+}
+
+func TestSafetyNetDemotes(t *testing.T) {
+	state, err := parseBoard(cornerPocket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	explanation := explainNextMove(state, moveContext{Profile: defaultProfile})
+	if explanation.Move == "left" {
+		t.Errorf("moved into the corner A can close")
+	}
+	if left, _ := explanation.explainMove("left"); left.Candidate || !left.Demoted {
+		t.Errorf("left should be demoted: %+v", left)
+	}
+
+	// With nothing better, the least trapped candidates are kept
+	explanation = MoveExplanation{Moves: []MoveBreakdown{{Move: "left", Candidate: true}}}
+	applySafetyNet(&explanation, state)
+	if !explanation.Moves[0].Candidate || explanation.Moves[0].Demoted {
+		t.Errorf("the only candidate was demoted: %+v", explanation.Moves[0])
+	}
+}
+
+// crowdedState puts the heads of up to opponents longer snakes two or more
+// cells from the cell to the right of our head, nearest first, each body
+// trailing away from it, on a board of the given size. The cells next to that
+// cell are left open, so every head near it has moves.
+func crowdedState(size, opponents int) GameState {
+	center := Coordinate{X: size / 2, Y: size / 2}
+	target := getNextPosition(center, "right")
+	state := GameState{Board: Board{Width: size, Height: size}}
+	state.Game.ID = "crowded"
+	state.Board.Snakes = []Snake{{ID: "you", Health: 90, Body: []Coordinate{center, getNextPosition(center, "left")}}}
+
+	occupied := map[Coordinate]bool{target: true}
+	for _, c := range state.Board.Snakes[0].Body {
+		occupied[c] = true
+	}
+	free := func(c Coordinate) bool { return onBoard(c, state.Board) && !occupied[c] }
+
+	// Heads go on the rings around the target, nearest first
+	for dist := 2; dist <= 2*size && len(state.Board.Snakes) <= opponents; dist++ {
+		for x := 0; x < size && len(state.Board.Snakes) <= opponents; x++ {
+			for y := 0; y < size && len(state.Board.Snakes) <= opponents; y++ {
+				head := Coordinate{X: x, Y: y}
+				if manhattanDistance(head, target) != dist || !free(head) {
+					continue
+				}
+				body := []Coordinate{head}
+				for len(body) < 3 {
+					last, next := body[len(body)-1], Coordinate{X: -1}
+					for _, dir := range allDirections {
+						c := getNextPosition(last, dir)
+						if free(c) && !slices.Contains(body, c) && manhattanDistance(c, target) > manhattanDistance(last, target) {
+							next = c
+						}
+					}
+					if next.X < 0 {
+						break
+					}
+					body = append(body, next)
+				}
+				if len(body) < 3 {
+					continue
+				}
+				for _, c := range body {
+					occupied[c] = true
+				}
+				id := fmt.Sprintf("snake-%d", len(state.Board.Snakes))
+				state.Board.Snakes = append(state.Board.Snakes, Snake{ID: id, Name: id, Health: 90, Body: body})
+			}
+		}
+	}
+	for i := range state.Board.Snakes {
+		snake := &state.Board.Snakes[i]
+		snake.Head, snake.Length = snake.Body[0], len(snake.Body)
+	}
+	state.You = state.Board.Snakes[0]
+	return state
+}
+
+func TestSafetyNetCapsRepliers(t *testing.T) {
+	state := crowdedState(11, 10)
+	if _, _, err := normalizeState(state, true); err != nil {
+		t.Fatalf("crowded state is invalid: %v", err)
+	}
+
+	next := getNextPosition(state.You.Head, "right")
+	options := map[string][]string{}
+	within := 0
+	for _, snake := range state.Board.Snakes[1:] {
+		if options[snake.ID] = legalMoves(state, snake.ID); len(options[snake.ID]) > 0 && manhattanDistance(next, snake.Head) <= replyReach {
+			within++
+		}
+	}
+	if within < 7 {
+		t.Fatalf("only %d opponents with moves are within reach; the test needs a crowd", within)
+	}
+	near := nearestRepliers(next, state, options)
+	if len(near) != maxReplySnakes {
+		t.Errorf("nearestRepliers = %v, expected %d of the %d within reach", near, maxReplySnakes, within)
+	}
+	for _, id := range near {
+		for _, snake := range state.Board.Snakes {
+			if snake.ID == id && manhattanDistance(next, snake.Head) > replyReach {
+				t.Errorf("%s is not among the nearest opponents", id)
+			}
+		}
+	}
+
+	// Only the nearest opponents are enumerated, so the crowd stays cheap
+	started := time.Now()
+	explainNextMove(state, moveContext{Profile: defaultProfile})
+	if elapsed := time.Since(started); elapsed > 250*time.Millisecond {
+		t.Errorf("deciding among %d nearby opponents took %v", within, elapsed)
+	}
+}
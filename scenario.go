@@ -0,0 +1,409 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Scenario is a board written in the ASCII scenario format together with the
+// moves the strategy is expected to make (or avoid) on it.
+//
+// The format is a handful of "key: value" headers followed by the grid, top
+// row first (highest y). Cells are separated by whitespace:
+//
+//	// comments start with "//"; a leading '#' is a hazard cell
+//	name: dodge the wall
+//	turn: 12
+//	ruleset: standard
+//	you: Y
+//	health: Y=40 A=90
+//	length: A=5
+//	require: up
+//	forbid: left down
+//	accept: up right
+//	. . * . .
+//	. Y y y' .
+//	. . # . .
+//
+// Glyphs:
+//
+//	.   empty cell
+//	*   food
+//	#   hazard
+//	A   head of snake "A" (any uppercase letter)
+//	a   body segment of snake "A"
+//	a'  tail of snake "A", only needed when the body path is ambiguous
+//
+// A cell may carry trailing '*' (food) or '#' (hazard) modifiers, e.g. "Y*"
+// for a head sitting on food or "a#" for a body segment inside a hazard.
+// Snakes default to 100 health; "length" stacks extra segments on the tail,
+// like a snake at the start of a game.
+type Scenario struct {
+	Name    string
+	State   GameState
+	Require string
+	Forbid  []string
+	Accept  []string
+}
+
+// checkMove reports whether move satisfies the scenario's expectations
+func (s Scenario) checkMove(move string) error {
+	if s.Require != "" && move != s.Require {
+		return fmt.Errorf("moved %s, required %s", move, s.Require)
+	}
+	for _, forbidden := range s.Forbid {
+		if move == forbidden {
+			return fmt.Errorf("moved %s, which is forbidden", move)
+		}
+	}
+	if len(s.Accept) > 0 {
+		for _, accepted := range s.Accept {
+			if move == accepted {
+				return nil
+			}
+		}
+		return fmt.Errorf("moved %s, acceptable moves are %v", move, s.Accept)
+	}
+	return nil
+}
+
+// loadScenario reads and parses a scenario file; the file name is used as the
+// scenario name unless the file sets one
+func loadScenario(path string) (Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Scenario{}, err
+	}
+	scenario, err := parseScenario(string(data))
+	if err != nil {
+		return Scenario{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if scenario.Name == "" {
+		scenario.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return scenario, nil
+}
+
+// parseBoard parses a scenario and returns only its game state
+func parseBoard(text string) (GameState, error) {
+	scenario, err := parseScenario(text)
+	if err != nil {
+		return GameState{}, err
+	}
+	return scenario.State, nil
+}
+
+// scenarioSnake collects the cells of one snake while the grid is scanned
+type scenarioSnake struct {
+	head *Coordinate
+	tail *Coordinate
+	body []Coordinate
+}
+
+// parseScenario parses the ASCII scenario format described on Scenario
+func parseScenario(text string) (Scenario, error) {
+	scenario := Scenario{}
+	state := GameState{}
+	state.Game.ID = "scenario"
+	state.Game.Ruleset.Name = "standard"
+
+	you := "Y"
+	healths := make(map[string]int)
+	lengths := make(map[string]int)
+	var rows [][]string
+	var rowLines []int
+
+	for i, raw := range strings.Split(text, "\n") {
+		lineNo := i + 1
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			key = strings.ToLower(strings.TrimSpace(key))
+			value = strings.TrimSpace(value)
+			if err := applyScenarioHeader(&scenario, &state, &you, healths, lengths, key, value); err != nil {
+				return Scenario{}, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			continue
+		}
+
+		rows = append(rows, strings.Fields(line))
+		rowLines = append(rowLines, lineNo)
+	}
+
+	if len(rows) == 0 {
+		return Scenario{}, fmt.Errorf("scenario has no board")
+	}
+
+	state.Board.Height = len(rows)
+	state.Board.Width = len(rows[0])
+	snakes := make(map[string]*scenarioSnake)
+
+	for r, row := range rows {
+		if len(row) != state.Board.Width {
+			return Scenario{}, fmt.Errorf("line %d: row has %d cells, expected %d", rowLines[r], len(row), state.Board.Width)
+		}
+		y := state.Board.Height - 1 - r
+		for x, token := range row {
+			pos := Coordinate{X: x, Y: y}
+			if err := applyScenarioCell(&state, snakes, pos, token); err != nil {
+				return Scenario{}, fmt.Errorf("line %d: cell %q at (%d,%d): %w", rowLines[r], token, x, y, err)
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(snakes))
+	for id := range snakes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	foundYou := false
+	for _, id := range ids {
+		cells := snakes[id]
+		if cells.head == nil {
+			return Scenario{}, fmt.Errorf("snake %s has no head", id)
+		}
+		body, err := orderSnakeBody(*cells.head, cells.body, cells.tail)
+		if err != nil {
+			return Scenario{}, fmt.Errorf("snake %s: %w", id, err)
+		}
+		if length, ok := lengths[id]; ok {
+			if length < len(body) {
+				return Scenario{}, fmt.Errorf("snake %s: length %d is shorter than its %d drawn segments", id, length, len(body))
+			}
+			for len(body) < length {
+				body = append(body, body[len(body)-1])
+			}
+		}
+
+		health := 100
+		if h, ok := healths[id]; ok {
+			health = h
+		}
+
+		snake := Snake{
+			ID:     id,
+			Name:   id,
+			Health: health,
+			Body:   body,
+			Head:   body[0],
+			Length: len(body),
+		}
+		state.Board.Snakes = append(state.Board.Snakes, snake)
+		if id == you {
+			state.You = snake
+			foundYou = true
+		}
+	}
+
+	for id := range healths {
+		if _, ok := snakes[id]; !ok {
+			return Scenario{}, fmt.Errorf("health set for unknown snake %s", id)
+		}
+	}
+	for id := range lengths {
+		if _, ok := snakes[id]; !ok {
+			return Scenario{}, fmt.Errorf("length set for unknown snake %s", id)
+		}
+	}
+	if !foundYou {
+		return Scenario{}, fmt.Errorf("snake %s (you) is not on the board", you)
+	}
+
+	scenario.State = state
+	return scenario, nil
+}
+
+func applyScenarioHeader(scenario *Scenario, state *GameState, you *string, healths, lengths map[string]int, key, value string) error {
+	switch key {
+	case "name":
+		scenario.Name = value
+	case "turn":
+		turn, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid turn %q", value)
+		}
+		state.Turn = turn
+	case "ruleset":
+		state.Game.Ruleset.Name = value
+	case "hazard-damage":
+		damage, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid hazard damage %q", value)
+		}
+		state.Game.Ruleset.Settings.HazardDamagePerTurn = damage
+	case "you":
+		if len(value) != 1 || !unicode.IsUpper(rune(value[0])) {
+			return fmt.Errorf("you must be a single uppercase letter, got %q", value)
+		}
+		*you = value
+	case "health":
+		return parseScenarioAssignments(value, healths)
+	case "length":
+		return parseScenarioAssignments(value, lengths)
+	case "require":
+		moves, err := parseScenarioMoves(value)
+		if err != nil {
+			return err
+		}
+		if len(moves) != 1 {
+			return fmt.Errorf("require takes exactly one move")
+		}
+		scenario.Require = moves[0]
+	case "forbid":
+		moves, err := parseScenarioMoves(value)
+		if err != nil {
+			return err
+		}
+		scenario.Forbid = append(scenario.Forbid, moves...)
+	case "accept":
+		moves, err := parseScenarioMoves(value)
+		if err != nil {
+			return err
+		}
+		scenario.Accept = append(scenario.Accept, moves...)
+	default:
+		return fmt.Errorf("unknown header %q", key)
+	}
+	return nil
+}
+
+// parseScenarioAssignments parses "A=90 B=40" into values keyed by snake letter
+func parseScenarioAssignments(value string, into map[string]int) error {
+	for _, field := range strings.Fields(value) {
+		id, number, ok := strings.Cut(field, "=")
+		if !ok || len(id) != 1 || !unicode.IsUpper(rune(id[0])) {
+			return fmt.Errorf("expected SNAKE=VALUE, got %q", field)
+		}
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return fmt.Errorf("invalid value in %q", field)
+		}
+		into[id] = n
+	}
+	return nil
+}
+
+func parseScenarioMoves(value string) ([]string, error) {
+	moves := strings.Fields(strings.ReplaceAll(value, ",", " "))
+	for _, move := range moves {
+		switch move {
+		case "up", "down", "left", "right":
+		default:
+			return nil, fmt.Errorf("unknown move %q", move)
+		}
+	}
+	return moves, nil
+}
+
+func applyScenarioCell(state *GameState, snakes map[string]*scenarioSnake, pos Coordinate, token string) error {
+	base := rune(token[0])
+	modifiers := token[1:]
+
+	switch {
+	case base == '.':
+	case base == '*':
+		state.Board.Food = append(state.Board.Food, pos)
+	case base == '#':
+		state.Board.Hazards = append(state.Board.Hazards, pos)
+	case unicode.IsLetter(base) && base < unicode.MaxASCII:
+		id := string(unicode.ToUpper(base))
+		cells, ok := snakes[id]
+		if !ok {
+			cells = &scenarioSnake{}
+			snakes[id] = cells
+		}
+		if unicode.IsUpper(base) {
+			if cells.head != nil {
+				return fmt.Errorf("snake %s has two heads", id)
+			}
+			head := pos
+			cells.head = &head
+		} else {
+			cells.body = append(cells.body, pos)
+		}
+	default:
+		return fmt.Errorf("unknown glyph %q", base)
+	}
+
+	for _, modifier := range modifiers {
+		switch modifier {
+		case '*':
+			state.Board.Food = append(state.Board.Food, pos)
+		case '#':
+			state.Board.Hazards = append(state.Board.Hazards, pos)
+		case '\'':
+			if !unicode.IsLower(base) {
+				return fmt.Errorf("only body segments can be marked as a tail")
+			}
+			cells := snakes[string(unicode.ToUpper(base))]
+			if cells.tail != nil {
+				return fmt.Errorf("snake has two tails")
+			}
+			tail := pos
+			cells.tail = &tail
+		default:
+			return fmt.Errorf("unknown modifier %q", modifier)
+		}
+	}
+	return nil
+}
+
+// orderSnakeBody finds the head-to-tail order of a snake's segments by
+// searching for a path from the head through every body cell. It fails when
+// the drawing admits more than one order and no tail is marked.
+func orderSnakeBody(head Coordinate, cells []Coordinate, tail *Coordinate) ([]Coordinate, error) {
+	remaining := make(map[Coordinate]bool, len(cells))
+	for _, cell := range cells {
+		remaining[cell] = true
+	}
+
+	var found []Coordinate
+	solutions := 0
+	path := []Coordinate{head}
+
+	var search func(pos Coordinate)
+	search = func(pos Coordinate) {
+		if solutions > 1 {
+			return
+		}
+		if len(remaining) == 0 {
+			if tail != nil && pos != *tail {
+				return
+			}
+			solutions++
+			if solutions == 1 {
+				found = append([]Coordinate(nil), path...)
+			}
+			return
+		}
+		for _, dir := range []string{"up", "down", "left", "right"} {
+			next := getNextPosition(pos, dir)
+			if !remaining[next] {
+				continue
+			}
+			delete(remaining, next)
+			path = append(path, next)
+			search(next)
+			path = path[:len(path)-1]
+			remaining[next] = true
+		}
+	}
+	search(head)
+
+	switch {
+	case solutions == 0:
+		return nil, fmt.Errorf("body segments do not form a connected path from the head")
+	case solutions > 1:
+		return nil, fmt.Errorf("body order is ambiguous, mark the tail with a trailing '")
+	}
+	return found, nil
+}
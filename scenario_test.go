@@ -0,0 +1,149 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// testState parses a board for a test and sets its game ID and turn
+func testState(t *testing.T, gameID string, turn int, board string) GameState {
+	t.Helper()
+	state, err := parseBoard(board)
+	if err != nil {
+		t.Fatalf("parseBoard: %v", err)
+	}
+	state.Game.ID = gameID
+	state.Turn = turn
+	return state
+}
+
+func TestParseScenario(t *testing.T) {
+	scenario, err := parseScenario(`
+// a comment
+name: parser smoke test
+turn: 42
+ruleset: royale
+hazard-damage: 14
+health: Y=54 A=7
+length: A=4
+forbid: left
+accept: up right
+# . * .
+. Y* . .
+. y y' A
+. . . a
+`)
+	if err != nil {
+		t.Fatalf("parseScenario: %v", err)
+	}
+
+	state := scenario.State
+	if scenario.Name != "parser smoke test" || state.Turn != 42 {
+		t.Errorf("headers not applied: name=%q turn=%d", scenario.Name, state.Turn)
+	}
+	if state.Game.Ruleset.Name != "royale" || state.Game.Ruleset.Settings.HazardDamagePerTurn != 14 {
+		t.Errorf("ruleset not applied: %+v", state.Game.Ruleset)
+	}
+	if state.Board.Width != 4 || state.Board.Height != 4 {
+		t.Errorf("board is %dx%d, expected 4x4", state.Board.Width, state.Board.Height)
+	}
+
+	wantFood := []Coordinate{{X: 2, Y: 3}, {X: 1, Y: 2}}
+	if !equalCoordinates(state.Board.Food, wantFood) {
+		t.Errorf("food = %v, expected %v", state.Board.Food, wantFood)
+	}
+	wantHazards := []Coordinate{{X: 0, Y: 3}}
+	if !equalCoordinates(state.Board.Hazards, wantHazards) {
+		t.Errorf("hazards = %v, expected %v", state.Board.Hazards, wantHazards)
+	}
+
+	wantYou := []Coordinate{{X: 1, Y: 2}, {X: 1, Y: 1}, {X: 2, Y: 1}}
+	if state.You.ID != "Y" || !equalCoordinates(state.You.Body, wantYou) {
+		t.Errorf("you = %s %v, expected Y %v", state.You.ID, state.You.Body, wantYou)
+	}
+	if state.You.Health != 54 || state.You.Length != 3 || state.You.Head != wantYou[0] {
+		t.Errorf("you health=%d length=%d head=%v", state.You.Health, state.You.Length, state.You.Head)
+	}
+
+	if len(state.Board.Snakes) != 2 {
+		t.Fatalf("expected 2 snakes, got %d", len(state.Board.Snakes))
+	}
+	opponent := state.Board.Snakes[0]
+	wantOpponent := []Coordinate{{X: 3, Y: 1}, {X: 3, Y: 0}, {X: 3, Y: 0}, {X: 3, Y: 0}}
+	if opponent.ID != "A" || opponent.Health != 7 || !equalCoordinates(opponent.Body, wantOpponent) {
+		t.Errorf("opponent = %s health=%d %v, expected A health=7 %v", opponent.ID, opponent.Health, opponent.Body, wantOpponent)
+	}
+
+	if err := scenario.checkMove("up"); err != nil {
+		t.Errorf("up should be acceptable: %v", err)
+	}
+	if err := scenario.checkMove("left"); err == nil {
+		t.Errorf("left should be forbidden")
+	}
+	if err := scenario.checkMove("down"); err == nil {
+		t.Errorf("down should not be acceptable")
+	}
+}
+
+func TestParseScenarioErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		board string
+	}{
+		{"no board", "turn: 3"},
+		{"ragged rows", ". . .\n. Y"},
+		{"missing you", ". A ."},
+		{"two heads", "Y . Y"},
+		{"body without head", "Y . a"},
+		{"disconnected body", "Y . y"},
+		{"ambiguous body", "y y\nY y"},
+		{"unknown glyph", "Y ?"},
+		{"unknown header", "colour: red\nY"},
+		{"bad move", "require: north\nY"},
+		{"length too short", "length: Y=1\nY y"},
+		{"health for unknown snake", "health: B=5\nY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseScenario(tt.board); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestScenarios(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "scenarios", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no scenarios found in testdata/scenarios")
+	}
+
+	for _, file := range files {
+		scenario, err := loadScenario(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run(scenario.Name, func(t *testing.T) {
+			move := calculateNextMove(scenario.State)
+			if err := scenario.checkMove(move); err != nil {
+				t.Errorf("%s: %v\n%s", file, err, renderBoard(scenario.State, renderOptions{}))
+			}
+		})
+	}
+}
+
+func equalCoordinates(a, b []Coordinate) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
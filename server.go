@@ -1,16 +1,23 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
 )
 
 type SnakeMoverFunc func(state GameState) BattlesnakeMoveResponse
 type SnakeStartFunc func(state GameState)
 type SnakeInfoFunc func() BattlesnakeInfoResponse
 type SnakeEndFunc func(state GameState)
+type SnakeExplainFunc func(state GameState) MoveExplanation
 
 // Start Battlesnake Server
 func RunServer() {
@@ -19,116 +26,157 @@ func RunServer() {
 		port = "8080"
 	}
 
-	http.HandleFunc("/claudia/", withServerID(HandleIndex))
-	http.HandleFunc("/claudia/start", withServerID(HandleStart))
-	http.HandleFunc("/claudia/move", withServerID(HandleMove))
-	http.HandleFunc("/claudia/end", withServerID(HandleEnd))
-
-	log.Printf("Running Battlesnake at http://0.0.0.0:%s...\n", port)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
-}
-
-func HandleStart(w http.ResponseWriter, r *http.Request) {
-	state := GameState{}
-	err := json.NewDecoder(r.Body).Decode(&state)
+	logger, err := newLogger(os.Stderr, os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT"))
 	if err != nil {
-		log.Printf("ERROR: Failed to decode start json, %s", err)
-		return
+		fatal("Failed to configure logging", err)
 	}
+	slog.SetDefault(logger)
 
-	// Nothing to respond with here
-}
-
-// HandleMove processes the move request and returns the next move
-func HandleMove(w http.ResponseWriter, r *http.Request) {
-	// Parse the request body
-	var gameState GameState
-	if err := json.NewDecoder(r.Body).Decode(&gameState); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+	cfg, err := loadServerConfig(os.Getenv("SNAKES_CONFIG"), os.Getenv)
+	if err != nil {
+		fatal("Failed to load snake config", err)
 	}
 
-	// Calculate the next move
-	nextMove := calculateNextMove(gameState)
+	fingerprints, err := openFingerprintDB(cfg.FingerprintDB)
+	if err != nil {
+		fatal("Failed to open opponent fingerprints", err)
+	}
 
-	w.Header().Set("Server", ServerID)
-	// Create the response
-	response := MoveResponse{
-		Move:  nextMove,
-		Shout: "Going " + nextMove + "!",
+	drainTimeout := defaultDrainTimeout
+	if v := os.Getenv("DRAIN_TIMEOUT"); v != "" {
+		if drainTimeout, err = time.ParseDuration(v); err != nil {
+			fatal("Failed to parse DRAIN_TIMEOUT", err)
+		}
 	}
 
-	// Set response headers
-	w.Header().Set("Content-Type", "application/json")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var draining atomic.Bool
+	mux := http.NewServeMux()
+	routes := cfg.routes(fingerprints)
+	for _, snake := range routes {
+		snake.Draining = draining.Load
+		registerSnake(mux, snake)
+		go snake.Sessions.runSweeper(ctx, time.Minute)
+		slog.Info("Serving snake", "server", snake.ServerID, "path", snake.Path)
+	}
+	mux.HandleFunc("/metrics", HandleMetrics)
+	registerDebug(mux, os.Getenv("DEBUG_TOKEN"), routes)
+
+	server := newHTTPServer(":"+port, mux)
+	errc := make(chan error, 1)
+	go func() { errc <- server.ListenAndServe() }()
+	slog.Info("Running Battlesnake", "addr", "http://0.0.0.0:"+port)
+
+	select {
+	case err := <-errc:
+		fatal("Server stopped", err)
+	case <-ctx.Done():
+	}
 
-	// Send the response
-	json.NewEncoder(w).Encode(response)
-}
+	// Keep answering /move so games in progress can finish, but turn away new
+	// ones, until every game has ended or the drain timeout passes
+	slog.Info("Shutting down, draining games in progress", "timeout", drainTimeout)
+	draining.Store(true)
+	drainGames(routes, drainTimeout, 100*time.Millisecond)
 
-func HandleEnd(w http.ResponseWriter, r *http.Request) {
-	state := GameState{}
-	err := json.NewDecoder(r.Body).Decode(&state)
-	if err != nil {
-		log.Printf("ERROR: Failed to decode end json, %s", err)
-		return
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Failed to finish in-flight requests", "err", err)
 	}
 
-	// Nothing to respond with here
+	for _, snake := range routes {
+		snake.Sessions.flush()
+	}
+	slog.Info("Shutdown complete")
 }
 
-// Middleware
-
-const ServerID = "battlesnake/dave-smith/claudia"
+const (
+	// maxRequestBytes bounds request bodies; a game state for a large board
+	// with many snakes is well under this
+	maxRequestBytes = 1 << 20
+	// defaultDrainTimeout is how long shutdown waits for games in progress,
+	// chosen to fit fly.io's default five second kill timeout
+	defaultDrainTimeout = 3 * time.Second
+	// shutdownTimeout is how long in-flight requests get once draining ends
+	shutdownTimeout = time.Second
+)
 
-func withServerID(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Server", ServerID)
-		if next != nil {
-			next(w, r)
-		}
+// newHTTPServer returns a server with timeouts suited to the Battlesnake
+// engine, which gives up on a move after the game's timeout (500ms by default)
+func newHTTPServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           http.MaxBytesHandler(handler, maxRequestBytes),
+		ReadHeaderTimeout: 2 * time.Second,
+		ReadTimeout:       5 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		IdleTimeout:       60 * time.Second,
 	}
 }
 
-func HandleIndex(w http.ResponseWriter, r *http.Request) {
-	response := BattlesnakeInfoResponse{
-		APIVersion: "1",
-		Author:     "Dave-Smith",
-		Color:      "#7ABF36",
-		Head:       "all-seeing",
-		Tail:       "do-sammy",
-		Version:    "0.0.1",
+// drainGames waits until no route has a game in progress or timeout passes,
+// checking every interval, and reports whether every game finished
+func drainGames(routes []SnakeRoute, timeout, interval time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		active := 0
+		for _, snake := range routes {
+			active += snake.Sessions.len()
+		}
+		if active == 0 {
+			return true
+		}
+		if !time.Now().Before(deadline) {
+			slog.Warn("Drain timeout passed with games in progress", "games", active)
+			return false
+		}
+		time.Sleep(interval)
 	}
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	err := json.NewEncoder(w).Encode(response)
-	if err != nil {
-		log.Printf("ERROR: Failed to encode info response, %s", err)
-	}
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
 }
 
+// Middleware
+
+const ServerID = "battlesnake/dave-smith/claudia"
+
 func SnakeHandlerMove(mover SnakeMoverFunc, serverId string, next http.HandlerFunc) http.HandlerFunc {
-	log.Printf("Move")
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Server", serverId)
 		if next != nil {
 			next(w, r)
 		}
 
-		state, err := unmarshalState(r)
+		logger := requestLogger(r, serverId)
+		state, err := readState(r, serverId, "move", logger)
 		if err != nil {
-			log.Printf("ERROR: Failed to decode move json, %s", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		log.Printf("[%s] Head position: (%d,%d), Body: %v, Health: %d, Length: %d", state.You.Name, state.You.Head.X, state.You.Head.Y, state.You.Body, state.You.Health, state.You.Length)
-
-		response := mover(state)
+		logger = gameLogger(logger, state)
+		logBoard(r, logger, state)
+
+		started := time.Now()
+		response, fallback := decideMove(mover, state, logger)
+		elapsed := time.Since(started)
+		metrics.moveDuration.observe(elapsed.Seconds(), gameLabelValues(serverId, state)...)
+		if fallback != "" {
+			metrics.fallbackMoves.inc(append(gameLabelValues(serverId, state), fallback)...)
+		}
 
-		log.Printf("[%s] Moving %s", state.You.Name, response.Move)
+		logger.Info("Moving", "move", response.Move, "health", state.You.Health, "length", state.You.Length, "elapsed", elapsed)
 
 		w.Header().Set("Content-Type", "application/json")
 		err = json.NewEncoder(w).Encode(response)
 		if err != nil {
-			log.Printf("ERROR: Failed to encode move response, %s", err)
+			logger.Error("Failed to encode move response", "err", err)
 			return
 		}
 	}
@@ -140,17 +188,17 @@ func SnakeHandlerStart(starter SnakeStartFunc, serverId string, next http.Handle
 		if next != nil {
 			next(w, r)
 		}
-		state, err := unmarshalState(r)
-		log.Printf("[%s] Starting new game", state.You.Name)
+		logger := requestLogger(r, serverId)
+		state, err := readState(r, serverId, "start", logger)
 		if err != nil {
-			log.Printf("ERROR: Failed to decode move json, %s", err)
 			return
 		}
-		log.Printf("[%s] Head position: (%d,%d), Body: %v, Health: %d, Length: %d", state.You.Name, state.You.Head.X, state.You.Head.Y, state.You.Body, state.You.Health, state.You.Length)
+		metrics.gamesStarted.inc(gameLabelValues(serverId, state)...)
+		logger = gameLogger(logger, state)
+		logger.Info("Starting new game", "ruleset", state.Game.Ruleset.Name, "board", fmt.Sprintf("%dx%d", state.Board.Width, state.Board.Height), "snakes", len(state.Board.Snakes))
+		logBoard(r, logger, state)
 
 		starter(state)
-
-		return
 	}
 }
 
@@ -167,22 +215,86 @@ func SnakeHandlerInfo(snakeInfo SnakeInfoFunc, serverId string, next http.Handle
 		w.Header().Set("Content-Type", "application/json")
 		err := json.NewEncoder(w).Encode(response)
 		if err != nil {
-			log.Printf("ERROR: Failed to encode info response, %s", err)
+			requestLogger(r, serverId).Error("Failed to encode info response", "err", err)
 		}
 	}
 }
 
 func SnakeHandlerEnd(gameEnd SnakeEndFunc, serverId string, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		state, err := unmarshalState(r)
+		w.Header().Set("Server", serverId)
+		if next != nil {
+			next(w, r)
+		}
+		logger := requestLogger(r, serverId)
+		state, err := readState(r, serverId, "end", logger)
 		if err != nil {
-			log.Printf("ERROR: Failed to decode move json, %s", err)
 			return
 		}
+		gameLogger(logger, state).Info("Game over", "result", gameResult(state))
 		gameEnd(state)
 	}
 }
 
+// SnakeHandlerExplain scores a game state like /move does and returns the
+// per-direction breakdown behind the chosen move
+func SnakeHandlerExplain(explainer SnakeExplainFunc, serverId string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", serverId)
+		if next != nil {
+			next(w, r)
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "POST a game state to explain", http.StatusMethodNotAllowed)
+			return
+		}
+
+		logger := requestLogger(r, serverId)
+		state, err := readState(r, serverId, "explain", logger)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(explainer(state))
+		if err != nil {
+			gameLogger(logger, state).Error("Failed to encode explain response", "err", err)
+		}
+	}
+}
+
+// logBoard logs the rendered board at debug level; rendering is skipped
+// entirely at higher levels
+func logBoard(r *http.Request, logger *slog.Logger, state GameState) {
+	if logger.Enabled(r.Context(), slog.LevelDebug) {
+		logger.Debug("Board", "board", "\n"+renderBoard(state, renderOptions{}))
+	}
+}
+
+// readState decodes and validates the request's game state, logging and
+// counting anything that had to be rejected or repaired
+func readState(r *http.Request, serverId, endpoint string, logger *slog.Logger) (GameState, error) {
+	state, err := unmarshalState(r)
+	if err != nil {
+		logger.Warn("Failed to decode "+endpoint+" json", "err", err)
+		metrics.decodeErrors.inc(serverId, endpoint)
+		return GameState{}, err
+	}
+
+	state, repairs, err := normalizeState(state, endpoint != "end")
+	if err != nil {
+		gameLogger(logger, state).Warn("Rejected invalid game state", "endpoint", endpoint, "err", err)
+		metrics.invalidStates.inc(serverId, endpoint, "rejected")
+		return GameState{}, fmt.Errorf("invalid game state: %w", err)
+	}
+	if len(repairs) > 0 {
+		gameLogger(logger, state).Warn("Repaired game state", "endpoint", endpoint, "repairs", repairs)
+		metrics.invalidStates.inc(serverId, endpoint, "repaired")
+	}
+	return state, nil
+}
+
 func unmarshalState(r *http.Request) (GameState, error) {
 	state := GameState{}
 	err := json.NewDecoder(r.Body).Decode(&state)
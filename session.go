@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultSessionTTL is how long a game may go without a request before the
+// sweeper assumes its /end was lost and evicts it
+const defaultSessionTTL = 5 * time.Minute
+
+// gameSession is everything we remember about one game between turns
+type gameSession struct {
+	mu sync.Mutex
+
+	GameID   string
+	Started  time.Time
+	LastSeen time.Time
+	// History holds every state we were sent for this game, oldest first,
+	// starting with the /start state
+	History []GameState
+	// Final is the /end state, set once the game is over
+	Final *GameState
+	// Opponents is the move history of every opponent, keyed by snake ID
+	Opponents map[string]*opponentLog
+}
+
+// previous returns the last state seen before the current turn, if any
+func (g *gameSession) previous() (GameState, bool) {
+	if len(g.History) < 2 {
+		return GameState{}, false
+	}
+	return g.History[len(g.History)-2], true
+}
+
+// frames returns the recorded game: every state seen, plus the final one
+func (g *gameSession) frames() []GameState {
+	frames := append([]GameState(nil), g.History...)
+	if g.Final != nil {
+		frames = append(frames, *g.Final)
+	}
+	return frames
+}
+
+// sessionStore keeps one gameSession per game for a single snake. /start
+// creates the session, /move reads and updates it and /end releases it;
+// sweep evicts games that never send /end.
+type sessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*gameSession
+	ttl      time.Duration
+	now      func() time.Time
+
+	// name identifies the snake in recording file names
+	name string
+	// recordDir, when set, receives a JSON Lines recording of every
+	// released game
+	recordDir string
+	// fingerprints, when set, seeds opponents from earlier games and learns
+	// from every released game
+	fingerprints *fingerprintDB
+}
+
+func newSessionStore(name string, ttl time.Duration, recordDir string, fingerprints *fingerprintDB) *sessionStore {
+	return &sessionStore{
+		sessions:     make(map[string]*gameSession),
+		ttl:          ttl,
+		now:          time.Now,
+		name:         name,
+		recordDir:    recordDir,
+		fingerprints: fingerprints,
+	}
+}
+
+// start creates the session for a new game, replacing any stale one
+func (s *sessionStore) start(state GameState) *gameSession {
+	now := s.now()
+	session := &gameSession{
+		GameID:   state.Game.ID,
+		Started:  now,
+		LastSeen: now,
+		History:  []GameState{state},
+	}
+	session.observeTurn()
+	s.fingerprints.seedSession(session)
+
+	s.mu.Lock()
+	s.sessions[state.Game.ID] = session
+	s.mu.Unlock()
+	return session
+}
+
+// move records a turn and calls fn with the game's session locked. A session
+// is created if /start was missed, e.g. after a restart in the middle of a game.
+func (s *sessionStore) move(state GameState, fn func(session *gameSession)) {
+	now := s.now()
+
+	s.mu.Lock()
+	session, ok := s.sessions[state.Game.ID]
+	if !ok {
+		session = &gameSession{GameID: state.Game.ID, Started: now}
+		s.sessions[state.Game.ID] = session
+	}
+	s.mu.Unlock()
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	session.LastSeen = now
+	// The /start state and a retried request repeat a turn we already have
+	if n := len(session.History); n > 0 && session.History[n-1].Turn == state.Turn {
+		session.History[n-1] = state
+	} else {
+		session.History = append(session.History, state)
+		session.observeTurn()
+	}
+	if fn != nil {
+		fn(session)
+	}
+}
+
+// end finalizes a game and releases its session
+func (s *sessionStore) end(state GameState) *gameSession {
+	s.mu.Lock()
+	session, ok := s.sessions[state.Game.ID]
+	delete(s.sessions, state.Game.ID)
+	s.mu.Unlock()
+
+	if !ok {
+		session = &gameSession{GameID: state.Game.ID, Started: s.now()}
+	}
+
+	session.mu.Lock()
+	final := state
+	session.Final = &final
+	session.LastSeen = s.now()
+	session.mu.Unlock()
+
+	s.release(session)
+	return session
+}
+
+// get returns the session for a game without updating it
+func (s *sessionStore) get(gameID string) (*gameSession, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[gameID]
+	return session, ok
+}
+
+// len returns the number of games in progress
+func (s *sessionStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sessions)
+}
+
+// sweep evicts every session idle for longer than the TTL and returns how
+// many were evicted
+func (s *sessionStore) sweep() int {
+	cutoff := s.now().Add(-s.ttl)
+	expired := s.evict(func(session *gameSession) bool {
+		return session.LastSeen.Before(cutoff)
+	})
+	for _, session := range expired {
+		slog.Info("Evicting idle game", "route", s.name, "game", session.GameID, "lastSeen", session.LastSeen.Format(time.RFC3339))
+		s.release(session)
+	}
+	return len(expired)
+}
+
+// flush releases every game still in progress, recording what was played so
+// far; it is called on shutdown and returns how many games were flushed
+func (s *sessionStore) flush() int {
+	flushed := s.evict(func(*gameSession) bool { return true })
+	for _, session := range flushed {
+		slog.Info("Flushing unfinished game", "route", s.name, "game", session.GameID)
+		s.release(session)
+	}
+	return len(flushed)
+}
+
+// evict removes and returns every session for which match reports true;
+// match is called with the session locked
+func (s *sessionStore) evict(match func(session *gameSession) bool) []*gameSession {
+	var evicted []*gameSession
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, session := range s.sessions {
+		session.mu.Lock()
+		ok := match(session)
+		session.mu.Unlock()
+		if ok {
+			evicted = append(evicted, session)
+			delete(s.sessions, id)
+		}
+	}
+	return evicted
+}
+
+// runSweeper calls sweep every interval until ctx is cancelled
+func (s *sessionStore) runSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep()
+		}
+	}
+}
+
+// release writes the recording of a game that has left the store and saves
+// what it taught us about the opponents
+func (s *sessionStore) release(session *gameSession) {
+	session.mu.Lock()
+	frames := session.frames()
+	logFingerprintError(session.GameID, s.fingerprints.recordSession(session))
+	session.mu.Unlock()
+
+	if s.recordDir == "" || len(frames) == 0 {
+		return
+	}
+	if err := s.writeRecording(session.GameID, frames); err != nil {
+		slog.Error("Failed to record game", "route", s.name, "game", session.GameID, "err", err)
+	}
+}
+
+func (s *sessionStore) writeRecording(gameID string, frames []GameState) error {
+	if err := os.MkdirAll(s.recordDir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.jsonl", sanitizeFileName(s.name), sanitizeFileName(gameID))
+	f, err := os.Create(filepath.Join(s.recordDir, name))
+	if err != nil {
+		return err
+	}
+	if err := writeGameRecord(f, frames); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// sanitizeFileName keeps IDs from the request out of other directories
+func sanitizeFileName(name string) string {
+	if name == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSessionLifecycle(t *testing.T) {
+	dir := t.TempDir()
+	store := newSessionStore("claudia", time.Minute, dir, nil)
+
+	store.start(testState(t, "game-1", 0, "Y"))
+	for turn := 0; turn < 3; turn++ {
+		store.move(testState(t, "game-1", turn, "Y"), func(session *gameSession) {
+			if got := session.History[len(session.History)-1].Turn; got != turn {
+				t.Errorf("latest state is turn %d, expected %d", got, turn)
+			}
+		})
+	}
+
+	session, ok := store.get("game-1")
+	if !ok {
+		t.Fatal("session missing before /end")
+	}
+	if len(session.History) != 3 {
+		t.Errorf("history has %d states, expected 3 (the /start state is replaced by turn 0)", len(session.History))
+	}
+	if previous, ok := session.previous(); !ok || previous.Turn != 1 {
+		t.Errorf("previous = turn %d %v, expected turn 1", previous.Turn, ok)
+	}
+
+	store.end(testState(t, "game-1", 3, "Y"))
+	if _, ok := store.get("game-1"); ok {
+		t.Errorf("session still stored after /end")
+	}
+
+	frames, err := loadGameRecord(filepath.Join(dir, "claudia-game-1.jsonl"))
+	if err != nil {
+		t.Fatalf("recording not written: %v", err)
+	}
+	if len(frames) != 4 || frames[3].Turn != 3 {
+		t.Errorf("recording has %d frames, expected turns 0-3", len(frames))
+	}
+}
+
+func TestSessionMoveWithoutStart(t *testing.T) {
+	store := newSessionStore("claudia", time.Minute, "", nil)
+	store.move(testState(t, "late", 40, "Y"), nil)
+	if store.len() != 1 {
+		t.Errorf("a /move for an unknown game should create its session")
+	}
+}
+
+func TestSessionSweep(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	store := newSessionStore("claudia", time.Minute, dir, nil)
+	store.now = func() time.Time { return now }
+
+	store.start(testState(t, "abandoned", 0, "Y"))
+	now = now.Add(30 * time.Second)
+	store.start(testState(t, "active", 0, "Y"))
+
+	now = now.Add(45 * time.Second)
+	if evicted := store.sweep(); evicted != 1 {
+		t.Fatalf("evicted %d sessions, expected 1", evicted)
+	}
+	if _, ok := store.get("abandoned"); ok {
+		t.Errorf("idle game was not evicted")
+	}
+	if _, ok := store.get("active"); !ok {
+		t.Errorf("active game was evicted")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "claudia-abandoned.jsonl")); err != nil {
+		t.Errorf("evicted game was not recorded: %v", err)
+	}
+}
+
+func TestSessionConcurrentGames(t *testing.T) {
+	store := newSessionStore("claudia", time.Minute, "", nil)
+	games := []string{"a", "b", "c", "d"}
+
+	var wg sync.WaitGroup
+	for _, id := range games {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			store.start(testState(t, id, 0, "Y"))
+			for turn := 1; turn <= 50; turn++ {
+				store.move(testState(t, id, turn, "Y"), nil)
+			}
+			store.end(testState(t, id, 51, "Y"))
+		}(id)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			store.sweep()
+		}
+	}()
+	wg.Wait()
+
+	if store.len() != 0 {
+		t.Errorf("%d sessions left after every game ended", store.len())
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	if got := sanitizeFileName("../../etc/passwd"); got != "______etc_passwd" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSessionFlush(t *testing.T) {
+	dir := t.TempDir()
+	store := newSessionStore("claudia", time.Minute, dir, nil)
+	store.start(testState(t, "unfinished", 0, "Y"))
+	store.move(testState(t, "unfinished", 1, "Y"), nil)
+
+	if flushed := store.flush(); flushed != 1 || store.len() != 0 {
+		t.Fatalf("flushed %d sessions leaving %d, expected 1 leaving 0", flushed, store.len())
+	}
+	frames, err := loadGameRecord(filepath.Join(dir, "claudia-unfinished.jsonl"))
+	if err != nil || len(frames) != 2 {
+		t.Errorf("flushed game recorded %d frames (%v), expected 2", len(frames), err)
+	}
+}
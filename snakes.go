@@ -0,0 +1,158 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// SnakeRoute is one snake served by this process: its path prefix, the server
+// ID it reports and the functions behind each Battlesnake endpoint
+type SnakeRoute struct {
+	// Path is the URL prefix the snake is mounted at, e.g. "/claudia/"
+	Path     string
+	ServerID string
+	Info     SnakeInfoFunc
+	Start    SnakeStartFunc
+	Move     SnakeMoverFunc
+	End      SnakeEndFunc
+	Explain  SnakeExplainFunc
+	// Sessions holds the snake's games in progress
+	Sessions *sessionStore
+	// Draining, when set and true, makes /start refuse new games while the
+	// server shuts down
+	Draining func() bool
+}
+
+// registerSnake mounts a snake's endpoints under its path prefix
+func registerSnake(mux *http.ServeMux, snake SnakeRoute) {
+	path := snake.Path
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+
+	mux.HandleFunc(path, SnakeHandlerInfo(snake.Info, snake.ServerID, nil))
+	mux.HandleFunc(path+"start", refuseWhileDraining(snake.Draining, snake.ServerID, SnakeHandlerStart(snake.Start, snake.ServerID, nil)))
+	mux.HandleFunc(path+"move", SnakeHandlerMove(snake.Move, snake.ServerID, nil))
+	mux.HandleFunc(path+"end", SnakeHandlerEnd(snake.End, snake.ServerID, nil))
+	if snake.Explain != nil {
+		mux.HandleFunc(path+"explain", SnakeHandlerExplain(snake.Explain, snake.ServerID, nil))
+	}
+}
+
+// refuseWhileDraining answers 503 instead of calling next once draining
+// reports true; a nil draining func never refuses
+func refuseWhileDraining(draining func() bool, serverId string, next http.HandlerFunc) http.HandlerFunc {
+	if draining == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if draining() {
+			w.Header().Set("Server", serverId)
+			requestLogger(r, serverId).Info("Refusing new game while draining")
+			w.Header().Set("Connection", "close")
+			http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// Personality is a named strategy tuning; how the snake presents itself
+// comes from its snakeConfig
+type Personality struct {
+	Name    string
+	Profile strategyProfile
+	// decide picks the move and explains it; nil means explainNextMove
+	decide func(GameState, moveContext) MoveExplanation
+}
+
+// route builds the SnakeRoute serving this personality at path, keeping
+// its games in sessions
+func (p Personality) route(path, serverID string, info BattlesnakeInfoResponse, sessions *sessionStore) SnakeRoute {
+	decide := p.decide
+	if decide == nil {
+		decide = explainNextMove
+	}
+	return SnakeRoute{
+		Path:     path,
+		ServerID: serverID,
+		Info:     func() BattlesnakeInfoResponse { return info },
+		Start: func(state GameState) {
+			sessions.start(state)
+		},
+		Move: func(state GameState) BattlesnakeMoveResponse {
+			// The session is locked only to record the turn and copy what it
+			// has learned. A decision that overruns the deadline keeps running
+			// after decideMove gives up on it, and must not hold up the next
+			// move of the game.
+			var mc moveContext
+			sessions.move(state, func(session *gameSession) {
+				mc = session.moveContext(p.Profile)
+			})
+			explanation := decide(state, mc)
+			metrics.recordDecision(serverID, state, explanation)
+			return moveResponse(explanation.Move)
+		},
+		End: func(state GameState) {
+			session := sessions.end(state)
+
+			var last *GameState
+			session.mu.Lock()
+			if n := len(session.History); n > 0 {
+				last = &session.History[n-1]
+			}
+			session.mu.Unlock()
+
+			metrics.recordGameEnd(serverID, state, last)
+		},
+		Explain: func(state GameState) MoveExplanation {
+			mc := moveContext{Profile: p.Profile}
+			if session, ok := sessions.get(state.Game.ID); ok {
+				session.mu.Lock()
+				mc = session.moveContext(p.Profile)
+				session.mu.Unlock()
+			}
+			return decide(state, mc)
+		},
+		Sessions: sessions,
+	}
+}
+
+func moveResponse(move string) BattlesnakeMoveResponse {
+	return BattlesnakeMoveResponse{
+		Move:  move,
+		Shout: "Going " + move + "!",
+	}
+}
+
+// aggressiveProfile hunts smaller snakes and tolerates more collision risk
+var aggressiveProfile = func() strategyProfile {
+	p := defaultProfile
+	p.RiskThreshold = 0.9
+	p.AggressionBonus = 120
+	p.DefensiveMultiplier = 0.85
+	p.HungryHealth = 70
+	p.TrapBonus = 400
+	p.DangerPenalty = 250
+	return p
+}()
+
+// cautiousProfile gives up food and kills for space and distance
+var cautiousProfile = func() strategyProfile {
+	p := defaultProfile
+	p.RiskThreshold = 0.5
+	p.SpaceWeight = 75
+	p.SpaceWeightLong = 100
+	p.AggressionBonus = 0
+	p.DefensiveMultiplier = 0.5
+	p.HazardMultiplier = 0.3
+	p.TrapBonus = 150
+	p.DangerPenalty = 600
+	return p
+}()
+
+var personalities = map[string]Personality{
+	"claudia":    {Name: "claudia", Profile: defaultProfile},
+	"aggressive": {Name: "aggressive", Profile: aggressiveProfile},
+	"cautious":   {Name: "cautious", Profile: cautiousProfile},
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegisterSnakeRoutes(t *testing.T) {
+	mux := http.NewServeMux()
+	for _, snake := range defaultServerConfig().routes(nil) {
+		registerSnake(mux, snake)
+	}
+
+	state, err := parseBoard(". . .\n. Y .\n. y' .")
+	if err != nil {
+		t.Fatalf("parseBoard: %v", err)
+	}
+	body, _ := json.Marshal(state)
+
+	for _, snake := range defaultServerConfig().routes(nil) {
+		t.Run(snake.Path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, snake.Path, nil))
+			if got := rec.Header().Get("Server"); got != snake.ServerID {
+				t.Errorf("Server header = %q, expected %q", got, snake.ServerID)
+			}
+			var info BattlesnakeInfoResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil || info.Color != snake.Info().Color {
+				t.Errorf("info response %q does not match the snake's info", rec.Body)
+			}
+
+			rec = httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, snake.Path+"move", strings.NewReader(string(body))))
+			var move BattlesnakeMoveResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &move); err != nil {
+				t.Fatalf("invalid move response %q: %v", rec.Body, err)
+			}
+			if move.Move == "down" || move.Move == "" {
+				t.Errorf("moved %q into our own neck", move.Move)
+			}
+
+			for _, endpoint := range []string{"start", "end"} {
+				rec = httptest.NewRecorder()
+				mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, snake.Path+endpoint, strings.NewReader(string(body))))
+				if rec.Code != http.StatusOK {
+					t.Errorf("/%s returned %d", endpoint, rec.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestMoveRejectsInvalidJSON(t *testing.T) {
+	mux := http.NewServeMux()
+	registerSnake(mux, personalities["claudia"].route("/claudia", ServerID, BattlesnakeInfoResponse{}, newSessionStore("claudia", defaultSessionTTL, "", nil)))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/claudia/move", strings.NewReader("{")))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid JSON returned %d, expected 400", rec.Code)
+	}
+}
+
+func TestStartRefusedWhileDraining(t *testing.T) {
+	draining := false
+	snake := personalities["claudia"].route("/claudia", ServerID, BattlesnakeInfoResponse{}, newSessionStore("claudia", defaultSessionTTL, "", nil))
+	snake.Draining = func() bool { return draining }
+	mux := http.NewServeMux()
+	registerSnake(mux, snake)
+
+	state, err := parseBoard(". . .\n. Y .\n. y' .")
+	if err != nil {
+		t.Fatalf("parseBoard: %v", err)
+	}
+	body, _ := json.Marshal(state)
+	post := func(endpoint string) int {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/claudia/"+endpoint, strings.NewReader(string(body))))
+		return rec.Code
+	}
+
+	if code := post("start"); code != http.StatusOK {
+		t.Fatalf("/start returned %d before draining", code)
+	}
+	draining = true
+	if code := post("start"); code != http.StatusServiceUnavailable {
+		t.Errorf("/start returned %d while draining, expected 503", code)
+	}
+	if code := post("move"); code != http.StatusOK {
+		t.Errorf("/move returned %d while draining, games in progress must finish", code)
+	}
+}
+
+func TestDrainGames(t *testing.T) {
+	store := newSessionStore("claudia", defaultSessionTTL, "", nil)
+	routes := []SnakeRoute{{Sessions: store}}
+	store.start(testState(t, "game", 0, "Y"))
+
+	if drainGames(routes, 20*time.Millisecond, time.Millisecond) {
+		t.Errorf("drain reported success with a game in progress")
+	}
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		store.end(testState(t, "game", 1, "Y"))
+	}()
+	if !drainGames(routes, time.Second, time.Millisecond) {
+		t.Errorf("drain timed out after the game ended")
+	}
+}
+
+func TestRequestBodyLimit(t *testing.T) {
+	mux := http.NewServeMux()
+	registerSnake(mux, personalities["claudia"].route("/claudia", ServerID, BattlesnakeInfoResponse{}, newSessionStore("claudia", defaultSessionTTL, "", nil)))
+	server := httptest.NewServer(newHTTPServer("", mux).Handler)
+	defer server.Close()
+
+	body := `{"turn": 1, "padding": "` + strings.Repeat("x", maxRequestBytes) + `"}`
+	resp, err := http.Post(server.URL+"/claudia/move", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("oversized body returned %d, expected 400", resp.StatusCode)
+	}
+}
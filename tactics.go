@@ -0,0 +1,126 @@
+package main
+
+const (
+	// trapReach is how close an opponent's head must be to the move for it
+	// to be considered for trapping; a move cannot enclose anything further
+	trapReach = 4
+	// trapSqueezeShare is the part of TrapBonus paid for shrinking a confined
+	// opponent's region without sealing it
+	trapSqueezeShare = 0.25
+)
+
+// trapScore rewards a move to pos that encloses an opponent running along a
+// wall or through a corridor: sealing it into fewer cells than its length
+// earns profile.TrapBonus, and shrinking its region earns a share of it. No
+// bonus is paid if the move leaves us less room than our own length.
+func trapScore(pos Coordinate, state GameState, profile strategyProfile) float64 {
+	if profile.TrapBonus == 0 || len(state.You.Body) == 0 {
+		return 0
+	}
+
+	before := freeTimes(state, nil)
+	after := freeTimes(state, &pos)
+	myLength := len(state.You.Body)
+
+	score := 0.0
+	safe := -1
+	for _, snake := range state.Board.Snakes {
+		if snake.ID == state.You.ID || manhattanDistance(pos, snake.Head) > trapReach || !confined(snake, state) {
+			continue
+		}
+
+		limit := max(2*snake.Length, 10)
+		was := reachableCells(snake.Head, before, state.Board, limit)
+		now := reachableCells(snake.Head, after, state.Board, limit)
+		if now >= was {
+			continue
+		}
+
+		// Only check our own room once a move is worth something
+		if safe < 0 {
+			safe = 0
+			if reachableFrom(pos, 1, after, state.Board, myLength) >= myLength {
+				safe = 1
+			}
+		}
+		if safe == 0 {
+			return 0
+		}
+
+		if now < snake.Length && was >= snake.Length {
+			score += profile.TrapBonus
+		} else {
+			score += profile.TrapBonus * trapSqueezeShare * float64(was-now) / float64(was)
+		}
+	}
+	return score
+}
+
+// confined reports whether a snake's head is on the edge of the board or in
+// a corridor, with at most two open neighbors
+func confined(snake Snake, state GameState) bool {
+	head := snake.Head
+	if head.X == 0 || head.Y == 0 || head.X == state.Board.Width-1 || head.Y == state.Board.Height-1 {
+		return true
+	}
+	open := 0
+	for _, dir := range allDirections {
+		if isValidMove(getNextPosition(head, dir), state) {
+			open++
+		}
+	}
+	return open <= 2
+}
+
+// freeTimes maps every occupied cell to the number of moves from now after
+// which it is free: a segment frees once the snake's tail has passed it.
+// When ourMove is set, our head moves there first, occupying it until our
+// whole body has followed.
+func freeTimes(state GameState, ourMove *Coordinate) map[Coordinate]int {
+	free := make(map[Coordinate]int)
+	occupy := func(c Coordinate, turns int) {
+		free[c] = max(free[c], turns)
+	}
+	for _, snake := range state.Board.Snakes {
+		for j, segment := range snake.Body {
+			occupy(segment, len(snake.Body)-j)
+		}
+	}
+	if ourMove != nil {
+		occupy(*ourMove, len(state.You.Body)+1)
+	}
+	return free
+}
+
+// reachableCells counts the cells a head at start can reach, up to limit
+func reachableCells(start Coordinate, free map[Coordinate]int, board Board, limit int) int {
+	return reachableFrom(start, 0, free, board, limit)
+}
+
+// reachableFrom counts the cells reachable from start, which is reached after
+// startTurns moves, without entering a cell before it is free. Counting stops
+// at limit.
+func reachableFrom(start Coordinate, startTurns int, free map[Coordinate]int, board Board, limit int) int {
+	type step struct {
+		pos   Coordinate
+		turns int
+	}
+	seen := map[Coordinate]bool{start: true}
+	queue := []step{{start, startTurns}}
+	count := 0
+	for len(queue) > 0 && count < limit {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dir := range allDirections {
+			next := getNextPosition(current.pos, dir)
+			turns := current.turns + 1
+			if seen[next] || !onBoard(next, board) || turns < free[next] {
+				continue
+			}
+			seen[next] = true
+			count++
+			queue = append(queue, step{next, turns})
+		}
+	}
+	return min(count, limit)
+}
@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+func TestTrapScore(t *testing.T) {
+	scenario, err := loadScenario("testdata/scenarios/seal-wall-runner.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := scenario.State
+	head := state.You.Head
+
+	if got := trapScore(getNextPosition(head, "right"), state, defaultProfile); got != defaultProfile.TrapBonus {
+		t.Errorf("capping the corridor scored %v, expected the full bonus %v", got, defaultProfile.TrapBonus)
+	}
+	if got := trapScore(getNextPosition(head, "up"), state, defaultProfile); got != 0 {
+		t.Errorf("moving away from the corridor scored %v, expected 0", got)
+	}
+
+	noBonus := defaultProfile
+	noBonus.TrapBonus = 0
+	if got := trapScore(getNextPosition(head, "right"), state, noBonus); got != 0 {
+		t.Errorf("trapping without a bonus scored %v", got)
+	}
+}
+
+func TestReachableCellsWaitsForTails(t *testing.T) {
+	state, err := parseBoard(`
+. . .
+. Y .
+. y' .
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	free := freeTimes(state, nil)
+	// Our tail frees after one move, so every other cell is reachable
+	if got := reachableCells(state.You.Head, free, state.Board, 100); got != 8 {
+		t.Errorf("reachableCells = %d, expected 8", got)
+	}
+	if got := reachableCells(state.You.Head, free, state.Board, 3); got != 3 {
+		t.Errorf("reachableCells with a limit of 3 = %d", got)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// maxBoardDimension bounds the board size we accept; official boards are at
+// most 25x25, and larger ones would only make flood fills expensive
+const maxBoardDimension = 200
+
+// normalizeState checks a decoded game state before the strategy sees it. It
+// returns the state with small inconsistencies repaired and a description of
+// each repair, or an error listing every problem it could not repair.
+//
+// requireYou is false for /end, where we are no longer on the board once
+// eliminated.
+func normalizeState(state GameState, requireYou bool) (GameState, []string, error) {
+	var repairs []string
+	var errs []error
+	repair := func(format string, args ...any) {
+		repairs = append(repairs, fmt.Sprintf(format, args...))
+	}
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	board := state.Board
+	if board.Width <= 0 || board.Height <= 0 || board.Width > maxBoardDimension || board.Height > maxBoardDimension {
+		return state, nil, fmt.Errorf("board is %dx%d, expected between 1x1 and %dx%d", board.Width, board.Height, maxBoardDimension, maxBoardDimension)
+	}
+	inBounds := func(c Coordinate) bool {
+		return c.X >= 0 && c.Y >= 0 && c.X < board.Width && c.Y < board.Height
+	}
+	wrapped := state.Game.Ruleset.Name == "wrapped"
+
+	// Copy everything we may repair so the caller's state is left alone
+	state.Board.Food = keepInBounds(board.Food, inBounds, func(c Coordinate) { repair("dropped food at %v outside the board", c) })
+	state.Board.Hazards = keepInBounds(board.Hazards, inBounds, func(c Coordinate) { repair("dropped hazard at %v outside the board", c) })
+	state.Board.Snakes = make([]Snake, len(board.Snakes))
+
+	ids := make(map[string]bool, len(board.Snakes))
+	// owners maps each occupied cell to the snake that first claimed it; a
+	// snake may stack its own segments, but two snakes never share a cell
+	owners := make(map[Coordinate]string)
+	for i, snake := range board.Snakes {
+		snake.Body = append([]Coordinate(nil), snake.Body...)
+		label := fmt.Sprintf("snake %d (%q)", i, snake.ID)
+
+		switch {
+		case snake.ID == "":
+			fail("%s has no ID", label)
+		case ids[snake.ID]:
+			fail("%s: ID is used by more than one snake", label)
+		}
+		ids[snake.ID] = true
+
+		if len(snake.Body) == 0 {
+			fail("%s has no body", label)
+			state.Board.Snakes[i] = snake
+			continue
+		}
+		for j, segment := range snake.Body {
+			if !inBounds(segment) {
+				fail("%s: body segment %d at %v is outside the board", label, j, segment)
+			} else if j > 0 && !segmentsConnected(snake.Body[j-1], segment, board, wrapped) {
+				fail("%s: body segment %d at %v does not touch segment %d at %v", label, j, segment, j-1, snake.Body[j-1])
+			}
+		}
+		for j, segment := range snake.Body {
+			owner, taken := owners[segment]
+			switch {
+			case !taken:
+				owners[segment] = label
+			case owner != label:
+				fail("%s: body segment %d at %v overlaps %s", label, j, segment, owner)
+			}
+		}
+
+		if snake.Head != snake.Body[0] {
+			repair("%s: head %v moved to the first body segment %v", label, snake.Head, snake.Body[0])
+			snake.Head = snake.Body[0]
+		}
+		if snake.Length != len(snake.Body) {
+			repair("%s: length %d set to the body length %d", label, snake.Length, len(snake.Body))
+			snake.Length = len(snake.Body)
+		}
+		if snake.Health < 0 || snake.Health > 100 {
+			repair("%s: health %d clamped to 0-100", label, snake.Health)
+			snake.Health = max(0, min(100, snake.Health))
+		}
+		state.Board.Snakes[i] = snake
+	}
+
+	if state.You.ID == "" {
+		fail("you has no ID")
+	}
+	found := false
+	for _, snake := range state.Board.Snakes {
+		if snake.ID == state.You.ID {
+			// The board copy has been checked; you is the same snake
+			state.You = snake
+			found = true
+		}
+	}
+	if !found && requireYou {
+		fail("you (%q) is not on the board", state.You.ID)
+	}
+
+	if len(errs) > 0 {
+		return state, nil, errors.Join(errs...)
+	}
+	return state, repairs, nil
+}
+
+// keepInBounds returns the coordinates inside the board, calling dropped for
+// every other one
+func keepInBounds(coords []Coordinate, inBounds func(Coordinate) bool, dropped func(Coordinate)) []Coordinate {
+	kept := make([]Coordinate, 0, len(coords))
+	for _, c := range coords {
+		if inBounds(c) {
+			kept = append(kept, c)
+		} else {
+			dropped(c)
+		}
+	}
+	return kept
+}
+
+// segmentsConnected reports whether two consecutive body segments are stacked
+// or adjacent, across the board edge on wrapped boards
+func segmentsConnected(a, b Coordinate, board Board, wrapped bool) bool {
+	dx, dy := abs(a.X-b.X), abs(a.Y-b.Y)
+	if wrapped {
+		dx = min(dx, board.Width-dx)
+		dy = min(dy, board.Height-dy)
+	}
+	return dx+dy <= 1
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// validBoard is a state that passes validation as it is
+const validBoard = ". . . .\n. Y . A\n. y . a\n. y' . a'"
+
+func TestNormalizeStateValid(t *testing.T) {
+	state := testState(t, "validate", 0, validBoard)
+	normalized, repairs, err := normalizeState(state, true)
+	if err != nil || len(repairs) > 0 {
+		t.Fatalf("valid state: repairs %v, err %v", repairs, err)
+	}
+	if normalized.You.ID != "Y" || len(normalized.Board.Snakes) != 2 {
+		t.Errorf("normalized state lost snakes: %+v", normalized.Board.Snakes)
+	}
+
+	// A snake that has just eaten stacks its own tail
+	state = testState(t, "validate", 0, "length: A=4\n"+validBoard)
+	if _, _, err := normalizeState(state, true); err != nil {
+		t.Errorf("a stacked tail was rejected: %v", err)
+	}
+}
+
+func TestNormalizeStateRepairs(t *testing.T) {
+	state := testState(t, "validate", 0, validBoard)
+	// Snakes are ordered by letter: A, then Y
+	state.Board.Snakes[1].Length = 7
+	state.Board.Snakes[1].Head = Coordinate{X: 3, Y: 3}
+	state.Board.Snakes[0].Health = 150
+	state.Board.Food = []Coordinate{{X: 0, Y: 0}, {X: 9, Y: 9}}
+	state.You.Length = 1
+
+	normalized, repairs, err := normalizeState(state, true)
+	if err != nil {
+		t.Fatalf("normalizeState: %v", err)
+	}
+	if len(repairs) != 4 {
+		t.Errorf("got %d repairs, expected 4: %q", len(repairs), repairs)
+	}
+	you := normalized.You
+	if you.Length != 3 || you.Head != you.Body[0] {
+		t.Errorf("you not repaired from the board copy: %+v", you)
+	}
+	if normalized.Board.Snakes[0].Health != 100 {
+		t.Errorf("health not clamped: %d", normalized.Board.Snakes[0].Health)
+	}
+	if len(normalized.Board.Food) != 1 || len(state.Board.Food) != 2 {
+		t.Errorf("food outside the board not dropped from a copy: %v / %v", normalized.Board.Food, state.Board.Food)
+	}
+	if state.Board.Snakes[1].Length != 7 {
+		t.Errorf("the caller's state was modified")
+	}
+}
+
+func TestNormalizeStateErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*GameState)
+		errMsg string
+	}{
+		{"zero board", func(s *GameState) { s.Board.Width = 0 }, "board is 0x4"},
+		{"huge board", func(s *GameState) { s.Board.Height = 1 << 20 }, "board is"},
+		{"missing you", func(s *GameState) { s.You.ID = "Z" }, `you ("Z") is not on the board`},
+		{"duplicate ID", func(s *GameState) { s.Board.Snakes[0].ID = "Y" }, "used by more than one snake"},
+		{"empty body", func(s *GameState) { s.Board.Snakes[1].Body = nil }, "has no body"},
+		{"out of bounds", func(s *GameState) { s.Board.Snakes[1].Body[2] = Coordinate{X: 4, Y: 0} }, "outside the board"},
+		{"gap in body", func(s *GameState) { s.Board.Snakes[1].Body[1] = Coordinate{X: 1, Y: 0} }, "does not touch"},
+		{"overlapping bodies", func(s *GameState) { s.Board.Snakes[0].Body = []Coordinate{{X: 2, Y: 1}, {X: 1, Y: 1}, {X: 1, Y: 0}} }, "overlaps snake 0"},
+		{"shared head", func(s *GameState) { s.Board.Snakes[0].Body = []Coordinate{{X: 1, Y: 2}, {X: 2, Y: 2}, {X: 3, Y: 2}} }, "overlaps snake 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := testState(t, "validate", 0, validBoard)
+			tt.modify(&state)
+			_, _, err := normalizeState(state, true)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("got error %v, expected one containing %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestNormalizeStateWrapped(t *testing.T) {
+	state := testState(t, "validate", 0, validBoard)
+	// Our body runs off the left edge and back in on the right
+	state.Board.Snakes[1].Body = []Coordinate{{X: 0, Y: 3}, {X: 3, Y: 3}, {X: 2, Y: 3}}
+	state.Board.Snakes[1].Head = Coordinate{X: 0, Y: 3}
+	if _, _, err := normalizeState(state, true); err == nil {
+		t.Errorf("a body crossing the edge is invalid on a standard board")
+	}
+	state.Game.Ruleset.Name = "wrapped"
+	if _, _, err := normalizeState(state, true); err != nil {
+		t.Errorf("wrapped body rejected: %v", err)
+	}
+}
+
+func TestNormalizeStateEndWithoutYou(t *testing.T) {
+	state := testState(t, "validate", 0, validBoard)
+	state.Board.Snakes = state.Board.Snakes[:1]
+	if _, _, err := normalizeState(state, false); err != nil {
+		t.Errorf("an /end state without us on the board was rejected: %v", err)
+	}
+}
+
+func TestMoveRejectsInvalidState(t *testing.T) {
+	state := testState(t, "validate", 0, validBoard)
+	state.You.ID = "ghost"
+	body, _ := json.Marshal(state)
+
+	called := false
+	rec := httptest.NewRecorder()
+	SnakeHandlerMove(func(GameState) BattlesnakeMoveResponse {
+		called = true
+		return moveResponse("up")
+	}, "battlesnake/test/validate", nil)(rec, httptest.NewRequest(http.MethodPost, "/move", bytes.NewReader(body)))
+
+	if called || rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "not on the board") {
+		t.Errorf("got %d %q (mover called: %v), expected a 400 naming the problem", rec.Code, rec.Body, called)
+	}
+	if got := metrics.invalidStates.value("battlesnake/test/validate", "move", "rejected"); got != 1 {
+		t.Errorf("rejected states counted = %v, expected 1", got)
+	}
+}